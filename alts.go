@@ -0,0 +1,448 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leighmacdonald/discordgo-lipstick/bot"
+	"github.com/leighmacdonald/steamid/v4/steamid"
+	"github.com/leighmacdonald/tf-api-discord/store"
+	"github.com/leighmacdonald/tf-api-discord/tfapi"
+)
+
+const (
+	// altMaxTeams limits how many of the most recent league teams have their rosters fetched.
+	altMaxTeams = 10
+	// altMaxLogs limits how many of the most recent logs.tf matches are checked for co-occurrence.
+	altMaxLogs = 15
+	// altMaxResults is the number of candidates shown.
+	altMaxResults = 10
+	// altMaxEvidence is the number of pieces of evidence shown per candidate, keeping the embed within
+	// discords size limits.
+	altMaxEvidence = 5
+	// altMinNameLength is the shortest normalized name that is considered when matching aliases, shorter
+	// names such as "a" or "tf" cause too many false positives.
+	altMinNameLength = 4
+)
+
+// The weights each signal adds to a candidates score.
+const (
+	// altWeightFriend and altWeightRemovedFriend are only added to candidates with another signal, most
+	// friends are not alts and players tend to have many removed friends.
+	altWeightFriend        = 1.0
+	altWeightRemovedFriend = 2.0
+	// altWeightTeammate is added for each shared team, rosters are large so it is a weak signal on its own.
+	altWeightTeammate = 0.5
+	// altWeightAlternating is added to teammates that never played in the same match as the player while
+	// they were on the team together, since an alt is played instead of the main account rather than
+	// alongside it.
+	altWeightAlternating = 2.0
+	altWeightAlias       = 3.0
+	altWeightMatch       = 0.5
+	altMaxMatchWeight    = 3.0
+)
+
+// altTeam is a team the candidate shared with the player, from and to are the period they were both on the
+// roster. A zero from or to means the period is open ended.
+type altTeam struct {
+	name string
+	from time.Time
+	to   time.Time
+}
+
+// overlaps checks if the players were on the team together during the period the logs were played in.
+func (t altTeam) overlaps(logs altLogs) bool {
+	if !t.from.IsZero() && !t.to.IsZero() && t.from.After(t.to) {
+		return false
+	}
+
+	return (t.from.IsZero() || !t.from.After(logs.to)) && (t.to.IsZero() || !t.to.Before(logs.from))
+}
+
+// altLogs describes the logs.tf matches of the player that were checked for co-occurrence.
+type altLogs struct {
+	checked int
+	from    time.Time
+	to      time.Time
+}
+
+// altCandidate is a player that may be an alternate account of the player being checked. The signals are
+// collected first, and only combined into a score once all of them are known.
+type altCandidate struct {
+	steamID steamid.SteamID
+	name    string
+	// friendSince is set for current steam friends, removedOn for friends that have since been removed.
+	friendSince time.Time
+	removedOn   time.Time
+	teams       []altTeam
+	matches     int
+	// alias is the alias of the player the candidates name matched.
+	alias    string
+	profile  *tfapi.Profile
+	score    float64
+	evidence []string
+}
+
+func (c *altCandidate) add(weight float64, evidence string) {
+	c.score += weight
+	c.evidence = append(c.evidence, evidence)
+}
+
+// evaluate computes the score and evidence from the signals, logs are the players logs that were checked for
+// co-occurrence.
+func (c *altCandidate) evaluate(logs altLogs) {
+	c.score, c.evidence = 0, nil
+
+	alternating := false
+
+	for _, team := range c.teams {
+		c.add(altWeightTeammate, "Teammate on "+team.name)
+
+		// Teammates from old rosters can't show up in recent logs, so not playing together only counts
+		// when they were on the team while the logs were played.
+		alternating = alternating || (logs.checked > 0 && team.overlaps(logs))
+	}
+
+	if alternating && c.matches == 0 {
+		c.add(altWeightAlternating, fmt.Sprintf("Never in the same match while teammates, of the last %d logs",
+			logs.checked))
+	}
+
+	if c.matches > 0 {
+		// Players that share most of their logs with the player are rare, unlike the regulars of a
+		// server or league that have played with everyone.
+		total := c.matches
+		if c.profile != nil {
+			total = max(total, c.profile.LogsCount)
+		}
+
+		rarity := float64(c.matches) / float64(total)
+		c.add(min(float64(c.matches)*altWeightMatch, altMaxMatchWeight)*rarity,
+			fmt.Sprintf("Played together in %d of the last %d logs, %.0f%% of their logs", c.matches, logs.checked,
+				rarity*100))
+	}
+
+	if c.alias != "" {
+		c.add(altWeightAlias, fmt.Sprintf("Name %q matches alias %q", c.name, c.alias))
+	}
+
+	if c.score == 0 {
+		return
+	}
+
+	if !c.removedOn.IsZero() {
+		c.add(altWeightRemovedFriend, "Removed as steam friend on "+c.removedOn.Format(time.DateOnly))
+	}
+
+	if !c.friendSince.IsZero() {
+		c.add(altWeightFriend, "Steam friends since "+c.friendSince.Format(time.DateOnly))
+	}
+}
+
+// altSearch collects the candidates found from each signal.
+type altSearch struct {
+	target     tfapi.Profile
+	candidates map[steamid.SteamID]*altCandidate
+	logs       altLogs
+}
+
+func (s *altSearch) candidate(steamID steamid.SteamID) *altCandidate {
+	candidate, found := s.candidates[steamID]
+	if !found {
+		candidate = &altCandidate{steamID: steamID}
+		s.candidates[steamID] = candidate
+	}
+
+	return candidate
+}
+
+func onAlts(api *tfapi.TFAPI, database *store.Store) bot.Handler {
+	return func(ctx context.Context, _ *discordgo.Session, interaction *discordgo.InteractionCreate) (*discordgo.MessageEmbed, error) {
+		opts := bot.OptionMap(interaction.ApplicationCommandData().Options)
+
+		playerID, errPlayerID := resolvePlayer(ctx, database, interaction, opts)
+		if errPlayerID != nil {
+			return nil, errPlayerID
+		}
+
+		profile, errProfile := api.Profile(ctx, playerID)
+		if errProfile != nil {
+			return nil, errors.Join(errProfile, bot.ErrCommandExec)
+		}
+
+		search := altSearch{target: profile, candidates: map[steamid.SteamID]*altCandidate{}}
+		search.addFriends()
+		search.addTeammates(ctx, api)
+		search.addMatches(ctx, api)
+
+		if errProfiles := search.addAliases(ctx, api); errProfiles != nil {
+			return nil, errors.Join(errProfiles, bot.ErrCommandExec)
+		}
+
+		return altsEmbed(ctx, profile, search.ranked()), nil
+	}
+}
+
+// addFriends adds the players friends, friends that have been removed are weighted higher since evaders
+// tend to drop the connection between their accounts. Neither counts without another signal.
+func (s *altSearch) addFriends() {
+	for _, friend := range s.target.Friends {
+		candidate := s.candidate(friend.SteamID)
+		if friend.RemovedOn.IsZero() {
+			candidate.friendSince = friend.FriendSince
+		} else {
+			candidate.removedOn = friend.RemovedOn
+		}
+	}
+}
+
+// addTeammates adds players that have been on the same league teams.
+func (s *altSearch) addTeammates(ctx context.Context, api *tfapi.TFAPI) {
+	teams := slices.Clone(s.target.CompetitiveTeams)
+	slices.SortFunc(teams, func(a, b tfapi.LeagueTeam) int {
+		return b.JoinedTeam.Compare(a.JoinedTeam)
+	})
+
+	teams = teams[:min(len(teams), altMaxTeams)]
+
+	for i, team := range teams {
+		if ctx.Err() != nil {
+			return
+		}
+
+		reportProgress(ctx, "Checking league teams, %d/%d done", i, len(teams))
+
+		members, errMembers := api.TeamMembers(ctx, team.League, team.LeagueID)
+		if errMembers != nil {
+			slog.Error("Failed to fetch team members", slog.String("error", errMembers.Error()),
+				slog.String("league", team.League))
+
+			continue
+		}
+
+		for _, member := range members {
+			if member.SteamID == s.target.SteamID {
+				continue
+			}
+
+			candidate := s.candidate(member.SteamID)
+			candidate.name = cmp.Or(candidate.name, member.Name)
+			candidate.teams = append(candidate.teams, sharedTeam(team, member))
+		}
+	}
+}
+
+// sharedTeam returns the period the player and the member were both on the team.
+func sharedTeam(team tfapi.LeagueTeam, member tfapi.LeagueTeamMember) altTeam {
+	shared := altTeam{
+		name: fmt.Sprintf("%s (%s)", team.TeamName, team.League),
+		from: team.JoinedTeam,
+		to:   team.LeftTeam,
+	}
+
+	if member.Joined.After(shared.from) {
+		shared.from = member.Joined
+	}
+
+	if !member.Left.IsZero() && (shared.to.IsZero() || member.Left.Before(shared.to)) {
+		shared.to = member.Left
+	}
+
+	return shared
+}
+
+// addMatches adds players that have played in the same logs.tf matches.
+func (s *altSearch) addMatches(ctx context.Context, api *tfapi.TFAPI) {
+	matches, errMatches := api.Logs(ctx, s.target.SteamID)
+	if errMatches != nil {
+		slog.Error("Failed to fetch logs", slog.String("error", errMatches.Error()))
+
+		return
+	}
+
+	slices.SortFunc(matches, func(a, b tfapi.Match) int {
+		return b.CreatedOn.Compare(a.CreatedOn)
+	})
+
+	checked := matches[:min(len(matches), altMaxLogs)]
+
+	for i, match := range checked {
+		if ctx.Err() != nil {
+			return
+		}
+
+		reportProgress(ctx, "Checking logs.tf matches, %d/%d done", i, len(checked))
+
+		detail, errDetail := api.Log(ctx, match.LogID)
+		if errDetail != nil {
+			slog.Error("Failed to fetch log", slog.String("error", errDetail.Error()), slog.Int64("log_id", match.LogID))
+
+			continue
+		}
+
+		s.logs.checked++
+
+		if s.logs.from.IsZero() || match.CreatedOn.Before(s.logs.from) {
+			s.logs.from = match.CreatedOn
+		}
+
+		if match.CreatedOn.After(s.logs.to) {
+			s.logs.to = match.CreatedOn
+		}
+
+		for _, player := range detail.Players {
+			if player.SteamID == s.target.SteamID {
+				continue
+			}
+
+			candidate := s.candidate(player.SteamID)
+			candidate.name = cmp.Or(candidate.name, player.Name)
+			candidate.matches++
+		}
+	}
+}
+
+// addAliases fetches the profiles of the candidates and compares their names and league aliases with those of
+// the player. Only candidates with another signal are checked since there is no way to search by name.
+func (s *altSearch) addAliases(ctx context.Context, api *tfapi.TFAPI) error {
+	targetNames := aliases(s.target)
+
+	steamIDs := make([]steamid.SteamID, 0, len(s.candidates))
+	for steamID := range s.candidates {
+		steamIDs = append(steamIDs, steamID)
+	}
+
+	if len(steamIDs) == 0 {
+		return nil
+	}
+
+	reportProgress(ctx, "Comparing the names of %d candidates", len(steamIDs))
+
+	profiles, errProfiles := api.Profiles(ctx, steamIDs...)
+	if errProfiles != nil {
+		return errProfiles
+	}
+
+	for _, profile := range profiles {
+		candidate, found := s.candidates[profile.SteamID]
+		if !found {
+			continue
+		}
+
+		candidate.profile = &profile
+		candidate.name = profile.PersonaName
+
+		for _, name := range aliases(profile) {
+			if alias, matched := matchAlias(name, targetNames); matched {
+				candidate.name, candidate.alias = name, alias
+
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// ranked scores the candidates and returns the highest scoring ones. Candidates without any signal that
+// counts on its own, such as those that are only friends, are left out.
+func (s *altSearch) ranked() []*altCandidate {
+	candidates := make([]*altCandidate, 0, len(s.candidates))
+	for _, candidate := range s.candidates {
+		candidate.evaluate(s.logs)
+		if candidate.score > 0 {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	slices.SortFunc(candidates, func(a, b *altCandidate) int {
+		return cmp.Or(cmp.Compare(b.score, a.score), cmp.Compare(a.steamID.Int64(), b.steamID.Int64()))
+	})
+
+	return candidates[:min(len(candidates), altMaxResults)]
+}
+
+// aliases returns the known names of a player.
+func aliases(profile tfapi.Profile) []string {
+	names := []string{profile.PersonaName}
+
+	for _, team := range profile.CompetitiveTeams {
+		if team.Alias != "" && !slices.Contains(names, team.Alias) {
+			names = append(names, team.Alias)
+		}
+	}
+
+	return names
+}
+
+// matchAlias checks if the name overlaps with any of the aliases, ignoring case, whitespace and symbols.
+func matchAlias(name string, aliases []string) (string, bool) {
+	normalized := normalizeName(name)
+	if len(normalized) < altMinNameLength {
+		return "", false
+	}
+
+	for _, alias := range aliases {
+		normalizedAlias := normalizeName(alias)
+		if len(normalizedAlias) < altMinNameLength {
+			continue
+		}
+
+		if strings.Contains(normalized, normalizedAlias) || strings.Contains(normalizedAlias, normalized) {
+			return alias, true
+		}
+	}
+
+	return "", false
+}
+
+func normalizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+
+		return -1
+	}, name)
+}
+
+func altsEmbed(ctx context.Context, profile tfapi.Profile, candidates []*altCandidate) *discordgo.MessageEmbed {
+	embed := newEmbed(ctx, "[Alts] "+profile.PersonaName).setProfile(profile)
+
+	if len(candidates) == 0 {
+		embed.setDescription("No likely alts found")
+
+		return embed.build()
+	}
+
+	embed.setDescription("Candidates are ranked by the combined weight of the evidence, none of it is conclusive on its own.")
+
+	for _, candidate := range candidates {
+		name := candidate.steamID.String()
+		if candidate.name != "" {
+			name = fmt.Sprintf("%s (%s)", candidate.name, candidate.steamID.String())
+		}
+
+		evidence := candidate.evidence[:min(len(candidate.evidence), altMaxEvidence)]
+		if hidden := len(candidate.evidence) - len(evidence); hidden > 0 {
+			evidence = append(evidence, fmt.Sprintf("...and %d more", hidden))
+		}
+
+		if candidate.profile != nil && profileStatus(*candidate.profile) == statusBanned {
+			evidence = append(evidence, "**Has active or steam bans**")
+			embed.setStatus(statusSuspicious)
+		}
+
+		embed.addField(fmt.Sprintf("%.1f - %s", candidate.score, name), strings.Join(evidence, "\n"))
+	}
+
+	return embed.build()
+}
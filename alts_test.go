@@ -0,0 +1,204 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/leighmacdonald/steamid/v4/steamid"
+	"github.com/leighmacdonald/tf-api-discord/tfapi"
+)
+
+func TestAltSearchScoring(t *testing.T) {
+	var (
+		now     = time.Date(2025, time.June, 1, 12, 0, 0, 0, time.UTC)
+		since   = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+		steamID = steamid.New(76561197960287930)
+		logs    = altLogs{checked: 15, from: time.Date(2025, time.May, 1, 0, 0, 0, 0, time.UTC), to: now}
+		current = altTeam{name: "A (etf2l)", from: since}
+		old     = altTeam{name: "B (rgl)", from: since.AddDate(-5, 0, 0), to: since.AddDate(-4, 0, 0)}
+	)
+
+	tests := []struct {
+		name      string
+		candidate altCandidate
+		logs      altLogs
+		want      float64
+	}{
+		{
+			name:      "friend only",
+			candidate: altCandidate{friendSince: since},
+			want:      0,
+		},
+		{
+			name:      "removed friend only",
+			candidate: altCandidate{removedOn: since},
+			want:      0,
+		},
+		{
+			name:      "removed friend and alias",
+			candidate: altCandidate{removedOn: since, name: "xXplayerXx", alias: "player"},
+			want:      altWeightAlias + altWeightRemovedFriend,
+		},
+		{
+			name:      "teammate in the same matches",
+			candidate: altCandidate{teams: []altTeam{current}, matches: 2, profile: &tfapi.Profile{LogsCount: 1000}},
+			logs:      logs,
+			want:      altWeightTeammate + altWeightMatch*2*2/1000,
+		},
+		{
+			name:      "teammate without logs checked",
+			candidate: altCandidate{teams: []altTeam{current, old}},
+			want:      altWeightTeammate * 2,
+		},
+		{
+			name:      "alternating teammate",
+			candidate: altCandidate{teams: []altTeam{current}},
+			logs:      logs,
+			want:      altWeightTeammate + altWeightAlternating,
+		},
+		{
+			name:      "old teammate",
+			candidate: altCandidate{teams: []altTeam{old}},
+			logs:      logs,
+			want:      altWeightTeammate,
+		},
+		{
+			name: "teammates at different times",
+			candidate: altCandidate{teams: []altTeam{
+				{name: "A (etf2l)", from: now.AddDate(0, -1, 0), to: now.AddDate(0, -2, 0)},
+			}},
+			logs: logs,
+			want: altWeightTeammate,
+		},
+		{
+			name:      "friend and alternating teammate",
+			candidate: altCandidate{friendSince: since, teams: []altTeam{current}},
+			logs:      logs,
+			want:      altWeightTeammate + altWeightAlternating + altWeightFriend,
+		},
+		{
+			name:      "server regular",
+			candidate: altCandidate{matches: 10, profile: &tfapi.Profile{LogsCount: 5000}},
+			logs:      logs,
+			want:      altMaxMatchWeight * 10 / 5000,
+		},
+		{
+			name:      "rare pair",
+			candidate: altCandidate{matches: 4, profile: &tfapi.Profile{LogsCount: 5}},
+			logs:      logs,
+			want:      altWeightMatch * 4 * 4 / 5,
+		},
+		{
+			name:      "rare pair capped",
+			candidate: altCandidate{matches: 10, profile: &tfapi.Profile{LogsCount: 10}},
+			logs:      logs,
+			want:      altMaxMatchWeight,
+		},
+		{
+			name:      "unknown log count",
+			candidate: altCandidate{matches: 1},
+			logs:      logs,
+			want:      altWeightMatch,
+		},
+		{
+			name:      "alias",
+			candidate: altCandidate{name: "xXplayerXx", alias: "player"},
+			want:      altWeightAlias,
+		},
+		{
+			name:      "friend and alias",
+			candidate: altCandidate{friendSince: since, name: "xXplayerXx", alias: "player"},
+			want:      altWeightAlias + altWeightFriend,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			candidate := test.candidate
+			candidate.steamID = steamID
+
+			search := altSearch{
+				candidates: map[steamid.SteamID]*altCandidate{steamID: &candidate},
+				logs:       test.logs,
+			}
+
+			ranked := search.ranked()
+			if test.want == 0 {
+				if len(ranked) != 0 {
+					t.Errorf("expected the candidate to be left out, got score %.2f", ranked[0].score)
+				}
+
+				return
+			}
+
+			if len(ranked) != 1 {
+				t.Fatalf("expected the candidate to be ranked, got %d candidates", len(ranked))
+			}
+
+			if diff := ranked[0].score - test.want; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("expected score %.4f, got %.4f", test.want, ranked[0].score)
+			}
+
+			if len(ranked[0].evidence) == 0 {
+				t.Error("expected evidence for the score")
+			}
+		})
+	}
+}
+
+func TestAltSearchRanked(t *testing.T) {
+	now := time.Date(2025, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+	search := altSearch{
+		candidates: map[steamid.SteamID]*altCandidate{},
+		logs:       altLogs{checked: 15, from: now.AddDate(0, -1, 0), to: now},
+	}
+
+	friends := make([]tfapi.Friend, 0, 20)
+	for i := range 20 {
+		friends = append(friends, tfapi.Friend{
+			SteamID:     steamid.New(76561197960287930 + int64(i)),
+			FriendSince: time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC),
+		})
+	}
+
+	search.target = tfapi.Profile{Friends: friends}
+	search.addFriends()
+
+	current := altTeam{name: "A (etf2l)", from: now.AddDate(-1, 0, 0)}
+
+	alternating := search.candidate(steamid.New(76561197960287935))
+	alternating.teams = []altTeam{current}
+
+	regular := search.candidate(steamid.New(76561197970669109))
+	regular.teams = []altTeam{current}
+	regular.matches = 12
+	regular.profile = &tfapi.Profile{LogsCount: 3000}
+
+	alias := search.candidate(steamid.New(76561197970669110))
+	alias.name, alias.alias = "player2", "player"
+
+	// Teammates from old rosters can't be in the recent logs, so they must not outrank the alias.
+	for i := range 5 {
+		old := search.candidate(steamid.New(76561197980000000 + int64(i)))
+		old.teams = []altTeam{{name: "B (rgl)", from: now.AddDate(-6, 0, 0), to: now.AddDate(-5, 0, 0)}}
+	}
+
+	// Removed friends without another signal are left out.
+	for i := range 5 {
+		search.candidate(steamid.New(76561197990000000 + int64(i))).removedOn = now.AddDate(0, -2, 0)
+	}
+
+	ranked := search.ranked()
+
+	want := []*altCandidate{alternating, alias, regular}
+	if len(ranked) != len(want)+5 {
+		t.Fatalf("expected %d candidates, got %d", len(want)+5, len(ranked))
+	}
+
+	for i, candidate := range want {
+		if ranked[i] != candidate {
+			t.Errorf("expected %s at position %d, got %s", candidate.steamID.String(), i, ranked[i].steamID.String())
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leighmacdonald/discordgo-lipstick/bot"
+	"github.com/leighmacdonald/steamid/v4/steamid"
+	"github.com/leighmacdonald/tf-api-discord/store"
+)
+
+const (
+	// auditMaxEntries limits how many entries are fetched for a single /audit query.
+	auditMaxEntries = 500
+	// auditMaxLines limits how many of the fetched entries are shown in the embed, the rest are only exported.
+	auditMaxLines = 20
+	// auditWriteTimeout bounds recording an entry, which happens after the command context may have expired.
+	auditWriteTimeout = time.Second * 5
+)
+
+// auditLogger writes audit entries as JSON regardless of the format of the default logger, so they can be
+// collected separately.
+var auditLogger = slog.New(slog.NewJSONHandler(os.Stdout, nil)).With(slog.String("log", "audit"))
+
+type auditTargetsKey struct{}
+
+// auditTargets collects the players looked up while handling a command.
+type auditTargets struct {
+	mu       sync.Mutex
+	steamIDs []steamid.SteamID
+}
+
+// withAuditTargets returns a copy of ctx that collects the players passed to addAuditTarget.
+func withAuditTargets(ctx context.Context) (context.Context, *auditTargets) {
+	targets := &auditTargets{}
+
+	return context.WithValue(ctx, auditTargetsKey{}, targets), targets
+}
+
+// addAuditTarget records that the current command looked up the players. It does nothing outside of
+// a command.
+func addAuditTarget(ctx context.Context, steamIDs ...steamid.SteamID) {
+	targets, ok := ctx.Value(auditTargetsKey{}).(*auditTargets)
+	if !ok {
+		return
+	}
+
+	targets.mu.Lock()
+	defer targets.mu.Unlock()
+
+	for _, steamID := range steamIDs {
+		if steamID.Valid() && !slices.Contains(targets.steamIDs, steamID) {
+			targets.steamIDs = append(targets.steamIDs, steamID)
+		}
+	}
+}
+
+func (t *auditTargets) list() []steamid.SteamID {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return slices.Clone(t.steamIDs)
+}
+
+// audit records the outcome of a command to the audit log and the database. Failing to record the entry
+// does not fail the command.
+func (r *router) audit(ctx context.Context, interaction *discordgo.InteractionCreate, targets *auditTargets,
+	outcome string, err error, elapsed time.Duration,
+) {
+	name, options := interactionOptions(interaction)
+
+	entry := store.AuditEntry{
+		GuildID:  interaction.GuildID,
+		UserID:   interactionUserID(interaction),
+		Command:  name,
+		Options:  options,
+		SteamIDs: targets.list(),
+		Status:   outcome,
+		Duration: elapsed,
+	}
+
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	steamIDs := make([]string, len(entry.SteamIDs))
+	for i, steamID := range entry.SteamIDs {
+		steamIDs[i] = steamID.String()
+	}
+
+	auditLogger.Info("Command invoked",
+		slog.String("guild_id", entry.GuildID),
+		slog.String("user_id", entry.UserID),
+		slog.String("command", entry.Command),
+		slog.Any("options", entry.Options),
+		slog.Any("steam_ids", steamIDs),
+		slog.String("status", entry.Status),
+		slog.String("error", entry.Error),
+		slog.Int64("duration_ms", entry.Duration.Milliseconds()))
+
+	writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), auditWriteTimeout)
+	defer cancel()
+
+	if errAudit := r.database.AddAuditEntry(writeCtx, &entry); errAudit != nil {
+		slog.Error("Failed to record audit entry", slog.String("error", errAudit.Error()))
+	}
+}
+
+// interactionOptions returns the full name of the invoked command, including any sub command, and the
+// values of its options. Context menu commands record their target as an option.
+func interactionOptions(interaction *discordgo.InteractionCreate) (string, map[string]string) {
+	data := interaction.ApplicationCommandData()
+	name := data.Name
+	options := map[string]string{}
+
+	if data.TargetID != "" {
+		options["target"] = data.TargetID
+	}
+
+	values := data.Options
+	for len(values) == 1 && (values[0].Type == discordgo.ApplicationCommandOptionSubCommand ||
+		values[0].Type == discordgo.ApplicationCommandOptionSubCommandGroup) {
+		name += " " + values[0].Name
+		values = values[0].Options
+	}
+
+	for _, option := range values {
+		options[option.Name] = fmt.Sprint(option.Value)
+	}
+
+	return name, options
+}
+
+func onAudit(database *store.Store) reporter {
+	return func(ctx context.Context, _ *discordgo.Session, interaction *discordgo.InteractionCreate) (response, report, error) {
+		opts := bot.OptionMap(interaction.ApplicationCommandData().Options)
+		location := guildSettings(ctx).Location()
+
+		filter := store.AuditFilter{
+			GuildID: interaction.GuildID,
+			UserID:  opts.String("user"),
+			Limit:   auditMaxEntries,
+		}
+
+		if target := opts.String("target"); target != "" {
+			steamID, errSteamID := resolveSteamID(ctx, target)
+			if errSteamID != nil || !steamID.Valid() {
+				return response{}, report{}, steamid.ErrInvalidSID
+			}
+
+			filter.SteamID = steamID
+		}
+
+		var errDate error
+
+		if filter.After, errDate = parseAuditDate(opts.String("since"), location); errDate != nil {
+			return response{}, report{}, errDate
+		}
+
+		if filter.Before, errDate = parseAuditDate(opts.String("until"), location); errDate != nil {
+			return response{}, report{}, errDate
+		}
+
+		// The until date is inclusive.
+		if !filter.Before.IsZero() {
+			filter.Before = filter.Before.AddDate(0, 0, 1)
+		}
+
+		entries, errEntries := database.AuditEntries(ctx, filter)
+		if errEntries != nil {
+			return response{}, report{}, fmt.Errorf("%w: failed to load audit log: %w", bot.ErrCommandExec, errEntries)
+		}
+
+		embed := newEmbed(ctx, "[Audit] Command log").setSource(sourceBot, time.Time{})
+
+		if len(entries) == 0 {
+			embed.setDescription("No matching entries")
+
+			return response{embeds: []*discordgo.MessageEmbed{embed.build()}}, auditReport(entries, location), nil
+		}
+
+		lines := []string{fmt.Sprintf("Showing %d of %d entries, use export for the full results",
+			min(len(entries), auditMaxLines), len(entries))}
+
+		for _, entry := range entries[:min(len(entries), auditMaxLines)] {
+			line := fmt.Sprintf("`%s` <@%s> `/%s` %s", entry.CreatedOn.In(location).Format(time.DateTime),
+				entry.UserID, entry.Command, entry.Status)
+
+			if len(entry.SteamIDs) > 0 {
+				line += " " + joinSteamIDs(entry.SteamIDs, ", ")
+			}
+
+			lines = append(lines, line)
+		}
+
+		embed.setDescription(strings.Join(lines, "\n"))
+
+		return response{embeds: []*discordgo.MessageEmbed{embed.build()}}, auditReport(entries, location), nil
+	}
+}
+
+// parseAuditDate parses a date in the guilds timezone, an empty value is the zero time.
+func parseAuditDate(value string, location *time.Location) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	date, errDate := time.ParseInLocation(time.DateOnly, value, location)
+	if errDate != nil {
+		return time.Time{}, fmt.Errorf("%w: dates must be in the YYYY-MM-DD format", bot.ErrCommandInvalid)
+	}
+
+	return date, nil
+}
+
+func joinSteamIDs(steamIDs []steamid.SteamID, sep string) string {
+	values := make([]string, len(steamIDs))
+	for i, steamID := range steamIDs {
+		values[i] = steamID.String()
+	}
+
+	return strings.Join(values, sep)
+}
+
+// auditReport flattens the entries into one row per command invocation.
+func auditReport(entries []store.AuditEntry, location *time.Location) report {
+	rows := make([][]string, len(entries))
+	for i, entry := range entries {
+		options := make([]string, 0, len(entry.Options))
+		for _, key := range slices.Sorted(maps.Keys(entry.Options)) {
+			options = append(options, key+"="+entry.Options[key])
+		}
+
+		rows[i] = []string{
+			strconv.FormatInt(entry.AuditID, 10),
+			entry.CreatedOn.In(location).Format(time.RFC3339),
+			entry.UserID,
+			entry.Command,
+			strings.Join(options, " "),
+			joinSteamIDs(entry.SteamIDs, " "),
+			entry.Status,
+			entry.Error,
+			strconv.FormatInt(entry.Duration.Milliseconds(), 10),
+		}
+	}
+
+	return report{
+		name:   "audit",
+		title:  "Audit log",
+		data:   entries,
+		header: []string{"audit_id", "created", "user_id", "command", "options", "steam_ids", "status", "error", "duration_ms"},
+		rows:   rows,
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leighmacdonald/steamid/v4/steamid"
+	"github.com/leighmacdonald/tf-api-discord/store"
+)
+
+const (
+	// maxChoices is the maximum number of autocomplete choices discord will accept.
+	maxChoices = 25
+	// maxChoiceName is the maximum length of the name of an autocomplete choice.
+	maxChoiceName = 100
+)
+
+// autocompleteHandler returns the choices to suggest for the focused option of a command.
+type autocompleteHandler func(ctx context.Context, session *discordgo.Session, interaction *discordgo.InteractionCreate, value string) ([]*discordgo.ApplicationCommandOptionChoice, error)
+
+// focusedOption returns the option the user is currently typing into, searching within sub commands.
+func focusedOption(options []*discordgo.ApplicationCommandInteractionDataOption) *discordgo.ApplicationCommandInteractionDataOption {
+	for _, option := range options {
+		if option.Focused {
+			return option
+		}
+
+		if focused := focusedOption(option.Options); focused != nil {
+			return focused
+		}
+	}
+
+	return nil
+}
+
+// suggestion is a previously seen player that can be offered as a steam id choice.
+type suggestion struct {
+	steamID steamid.SteamID
+	name    string
+}
+
+func (s suggestion) matches(query string) bool {
+	if query == "" {
+		return true
+	}
+
+	query = strings.ToLower(query)
+
+	return strings.Contains(strings.ToLower(s.name), query) || strings.Contains(s.steamID.String(), query)
+}
+
+func (s suggestion) choice() *discordgo.ApplicationCommandOptionChoice {
+	name := s.steamID.String()
+	if s.name != "" {
+		name = s.name + " (" + name + ")"
+	}
+
+	if runes := []rune(name); len(runes) > maxChoiceName {
+		name = string(runes[:maxChoiceName])
+	}
+
+	return &discordgo.ApplicationCommandOptionChoice{Name: name, Value: s.steamID.String()}
+}
+
+// onSteamIDAutocomplete suggests players from the users own linked account and recent lookups and, for
+// moderators, the guild watchlist and the linked accounts of guild members. When the value typed is a full
+// steam id, profile url or vanity name that resolves, it is suggested first.
+func onSteamIDAutocomplete(database *store.Store) autocompleteHandler {
+	return func(ctx context.Context, session *discordgo.Session, interaction *discordgo.InteractionCreate, value string) ([]*discordgo.ApplicationCommandOptionChoice, error) {
+		query := strings.TrimSpace(value)
+
+		suggestions, errSuggestions := steamIDSuggestions(ctx, database, session, interaction)
+		if errSuggestions != nil {
+			return nil, errSuggestions
+		}
+
+		var (
+			choices []*discordgo.ApplicationCommandOptionChoice
+			seen    = map[steamid.SteamID]bool{}
+		)
+
+		if query != "" && !strings.ContainsAny(query, " \t") {
+			if resolved, errResolve := resolveSteamID(ctx, query); errResolve == nil && resolved.Valid() {
+				name := ""
+				if resolved.String() != query {
+					name = query
+				}
+
+				choices = append(choices, suggestion{steamID: resolved, name: name}.choice())
+				seen[resolved] = true
+			}
+		}
+
+		for _, player := range suggestions {
+			if len(choices) == maxChoices {
+				break
+			}
+
+			if seen[player.steamID] || !player.matches(query) {
+				continue
+			}
+
+			choices = append(choices, player.choice())
+			seen[player.steamID] = true
+		}
+
+		return choices, nil
+	}
+}
+
+func steamIDSuggestions(ctx context.Context, database *store.Store, session *discordgo.Session, interaction *discordgo.InteractionCreate) ([]suggestion, error) {
+	userID := interactionUserID(interaction)
+
+	var suggestions []suggestion
+
+	link, errLink := database.AccountLink(ctx, userID)
+	if errLink != nil && !errors.Is(errLink, store.ErrNoResult) {
+		return nil, errLink
+	}
+
+	if errLink == nil {
+		suggestions = append(suggestions, suggestion{steamID: link.SteamID, name: "Your account"})
+	}
+
+	lookups, errLookups := database.Lookups(ctx, userID, maxChoices*2)
+	if errLookups != nil {
+		return nil, errLookups
+	}
+
+	for _, lookup := range lookups {
+		suggestions = append(suggestions, suggestion{steamID: lookup.SteamID, name: lookup.PersonaName})
+	}
+
+	// The watchlist and member links are only visible to moderators, so don't leak them to other members.
+	if interaction.GuildID == "" || memberPermission(guildSettings(ctx), interaction.Member) < permissionModerator {
+		return suggestions, nil
+	}
+
+	watches, errWatches := database.Watches(ctx, interaction.GuildID)
+	if errWatches != nil {
+		return nil, errWatches
+	}
+
+	for _, watch := range watches {
+		suggestions = append(suggestions, suggestion{steamID: watch.SteamID, name: watchedName(ctx, database, watch)})
+	}
+
+	for _, member := range guildLinks(ctx, database, session, interaction.GuildID) {
+		suggestions = append(suggestions, suggestion{steamID: member.SteamID, name: memberName(session, interaction.GuildID, member.UserID)})
+	}
+
+	return suggestions, nil
+}
+
+// memberName returns the display name of a guild member, as known by the session state.
+func memberName(session *discordgo.Session, guildID string, userID string) string {
+	member, errMember := session.State.Member(guildID, userID)
+	if errMember != nil || member.User == nil {
+		return ""
+	}
+
+	return member.DisplayName()
+}
+
+// watchedName returns the last known name of a watched player, falling back to the watch note when the
+// player has not been polled yet.
+func watchedName(ctx context.Context, database *store.Store, watch store.Watch) string {
+	snapshot, errSnapshot := database.Snapshot(ctx, watch.SteamID, store.SnapshotWatch)
+	if errSnapshot != nil {
+		return watch.Note
+	}
+
+	var state watchState
+	if err := snapshot.Decode(&state); err != nil {
+		slog.Error("Failed to decode watch snapshot", slog.String("error", err.Error()))
+
+		return watch.Note
+	}
+
+	return state.PersonaName
+}
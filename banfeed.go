@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leighmacdonald/discordgo-lipstick/bot"
+	"github.com/leighmacdonald/steamid/v4/steamid"
+	"github.com/leighmacdonald/tf-api-discord/store"
+	"github.com/leighmacdonald/tf-api-discord/tfapi"
+)
+
+const banFeedInterval = time.Minute * 10
+
+var errNotSubscribed = errors.New("not subscribed to site")
+
+func onBanFeed(database *store.Store) bot.Handler {
+	return func(ctx context.Context, session *discordgo.Session, interaction *discordgo.InteractionCreate) (*discordgo.MessageEmbed, error) {
+		name, opts := subCommand(interaction)
+
+		switch name {
+		case "subscribe":
+			feed := store.BanFeed{
+				GuildID:   interaction.GuildID,
+				SiteName:  opts.String("site"),
+				ChannelID: opts.String("channel"),
+			}
+
+			if err := database.SaveBanFeed(ctx, &feed); err != nil {
+				return nil, errors.Join(err, bot.ErrCommandExec)
+			}
+
+			return newEmbed(ctx, "[Ban Feed] Subscribed to "+feed.SiteName).
+				setSource(sourceBot, time.Time{}).
+				setDescription(fmt.Sprintf("New bans will be posted to <#%s>", feed.ChannelID)).
+				build(), nil
+		case "unsubscribe":
+			if err := database.RemoveBanFeed(ctx, interaction.GuildID, opts.String("site")); err != nil {
+				if errors.Is(err, store.ErrNoResult) {
+					return nil, errNotSubscribed
+				}
+
+				return nil, errors.Join(err, bot.ErrCommandExec)
+			}
+
+			return newEmbed(ctx, "[Ban Feed] Unsubscribed from "+opts.String("site")).
+				setSource(sourceBot, time.Time{}).
+				build(), nil
+		case "list":
+			feeds, errFeeds := database.BanFeeds(ctx, interaction.GuildID)
+			if errFeeds != nil {
+				return nil, errors.Join(errFeeds, bot.ErrCommandExec)
+			}
+
+			embed := newEmbed(ctx, "[Ban Feed] Subscriptions").setSource(sourceBot, time.Time{})
+			if len(feeds) == 0 {
+				embed.setDescription("Not subscribed to any sites")
+			}
+
+			for _, feed := range feeds {
+				embed.addFieldInline(feed.SiteName, "<#"+feed.ChannelID+">")
+			}
+
+			return embed.build(), nil
+		default:
+			return nil, fmt.Errorf("%w: unknown sub command: %s", bot.ErrCommandInvalid, name)
+		}
+	}
+}
+
+// banFeed periodically checks the players each guild cares about for new bans from the sites
+// the guild has subscribed to.
+type banFeed struct {
+	api      *tfapi.TFAPI
+	database *store.Store
+	session  *discordgo.Session
+	links    embedConfig
+}
+
+func newBanFeed(api *tfapi.TFAPI, database *store.Store, session *discordgo.Session, links embedConfig) *banFeed {
+	return &banFeed{api: api, database: database, session: session, links: links}
+}
+
+func (f *banFeed) start(ctx context.Context) {
+	ctx = withEmbedLinks(ctx, f.links)
+
+	ticker := time.NewTicker(banFeedInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			f.update(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (f *banFeed) update(ctx context.Context) {
+	feeds, errFeeds := f.database.BanFeeds(ctx, "")
+	if errFeeds != nil {
+		slog.Error("Failed to load ban feeds", slog.String("error", errFeeds.Error()))
+
+		return
+	}
+
+	guildFeeds := map[string][]store.BanFeed{}
+	for _, feed := range feeds {
+		guildFeeds[feed.GuildID] = append(guildFeeds[feed.GuildID], feed)
+	}
+
+	// Players can be relevant to multiple guilds, only fetch their bans once per update.
+	playerBans := map[steamid.SteamID][]tfapi.SourceBan{}
+
+	for guildID, subscriptions := range guildFeeds {
+		players, errPlayers := f.players(ctx, guildID)
+		if errPlayers != nil {
+			slog.Error("Failed to load ban feed players", slog.String("error", errPlayers.Error()),
+				slog.String("guild_id", guildID))
+
+			continue
+		}
+
+		for _, steamID := range players {
+			if ctx.Err() != nil {
+				return
+			}
+
+			bans, found := playerBans[steamID]
+			if !found {
+				fetched, errBans := f.api.Bans(ctx, steamID, "")
+				if errBans != nil {
+					slog.Error("Failed to fetch bans", slog.String("error", errBans.Error()),
+						slog.String("steam_id", steamID.String()))
+
+					continue
+				}
+
+				bans = fetched
+				playerBans[steamID] = bans
+			}
+
+			f.announce(ctx, subscriptions, steamID, bans)
+		}
+	}
+}
+
+// players returns all the players that the guild cares about, the watchlist and the linked accounts of
+// its members.
+func (f *banFeed) players(ctx context.Context, guildID string) ([]steamid.SteamID, error) {
+	watches, errWatches := f.database.Watches(ctx, guildID)
+	if errWatches != nil {
+		return nil, errWatches
+	}
+
+	players := make([]steamid.SteamID, 0, len(watches))
+	for _, watch := range watches {
+		players = append(players, watch.SteamID)
+	}
+
+	for _, link := range guildLinks(ctx, f.database, f.session, guildID) {
+		if !slices.Contains(players, link.SteamID) {
+			players = append(players, link.SteamID)
+		}
+	}
+
+	return players, nil
+}
+
+// announce posts the bans of the player that have not been announced yet. The first time a player is checked
+// for a subscription, such as after subscribing, adding them to the watchlist or linking their account, their
+// existing bans are only recorded, otherwise every historical ban would be posted. After that any ban not yet
+// announced is, however long ago it was created, so bans that tf-api finds late are not missed.
+func (f *banFeed) announce(ctx context.Context, subscriptions []store.BanFeed, steamID steamid.SteamID, bans []tfapi.SourceBan) {
+	for _, subscription := range subscriptions {
+		settings, errSettings := f.database.GuildSettings(ctx, subscription.GuildID)
+		if errSettings != nil {
+			slog.Error("Failed to load guild settings", slog.String("error", errSettings.Error()))
+
+			continue
+		}
+
+		seeded, errSeeded := f.database.BanFeedSeeded(ctx, subscription, steamID)
+		if errSeeded != nil {
+			slog.Error("Failed to check seeded ban feed player", slog.String("error", errSeeded.Error()))
+
+			continue
+		}
+
+		// The player is only seeded once all their existing bans are recorded, otherwise the ones that
+		// failed would be announced on the next update.
+		recorded := true
+
+		for _, ban := range bans {
+			if ban.SiteName != subscription.SiteName {
+				continue
+			}
+
+			announced := store.AnnouncedBan{
+				GuildID:    subscription.GuildID,
+				SiteName:   ban.SiteName,
+				SteamID:    ban.SteamID,
+				BanCreated: ban.CreatedOn,
+			}
+
+			exists, errExists := f.database.BanAnnounced(ctx, announced)
+			if errExists != nil {
+				slog.Error("Failed to check announced ban", slog.String("error", errExists.Error()))
+
+				recorded = false
+
+				continue
+			}
+
+			if exists {
+				continue
+			}
+
+			if !seeded {
+				if err := f.database.AddAnnouncedBan(ctx, &announced); err != nil {
+					slog.Error("Failed to record existing ban", slog.String("error", err.Error()))
+
+					recorded = false
+				}
+
+				continue
+			}
+
+			if _, err := f.session.ChannelMessageSendEmbed(subscription.ChannelID, banFeedEmbed(withGuildSettings(ctx, settings), ban)); err != nil {
+				slog.Error("Failed to send ban feed message", slog.String("error", err.Error()),
+					slog.String("guild_id", subscription.GuildID))
+
+				continue
+			}
+
+			if err := f.database.AddAnnouncedBan(ctx, &announced); err != nil {
+				slog.Error("Failed to record announced ban", slog.String("error", err.Error()))
+			}
+		}
+
+		if seeded || !recorded {
+			continue
+		}
+
+		if err := f.database.SetBanFeedSeeded(ctx, subscription, steamID); err != nil {
+			slog.Error("Failed to record seeded ban feed player", slog.String("error", err.Error()),
+				slog.String("guild_id", subscription.GuildID))
+		}
+	}
+}
+
+func banFeedEmbed(ctx context.Context, ban tfapi.SourceBan) *discordgo.MessageEmbed {
+	embed := newEmbed(ctx, "[Ban Feed] New ban on "+ban.SiteName).
+		setPlayerURL(ban.SteamID).
+		setStatus(statusBanned)
+
+	embed.addFieldInline("Name", ban.Name)
+	embed.addFieldInline("SteamID", ban.SteamID.String())
+	embed.addFieldInline("Reason", ban.Reason)
+	embed.addFieldInline("Created", formatDate(ctx, ban.CreatedOn))
+	embed.addFieldInline("Expires", banExpiry(ban, guildSettings(ctx).Location()))
+
+	return embed.build()
+}
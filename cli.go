@@ -0,0 +1,349 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leighmacdonald/tf-api-discord/store"
+	"github.com/leighmacdonald/tf-api-discord/tfapi"
+)
+
+const formatText = "text"
+
+var (
+	errCLIUsage   = errors.New("invalid command line")
+	errCLICommand = errors.New("unknown command")
+
+	reMarkdownLink = regexp.MustCompile(`\[([^\]]+)]\((\S+)\)`)
+)
+
+// cliCommands are the commands that can be run from the terminal. They only read data, and don't depend
+// on the guild or user they are invoked by.
+var cliCommands = []string{"check", "bulkcheck", "bans", "stats", "logs", "alts", "friends"}
+
+// runCLI runs a single command from the terminal instead of discord and writes the response to stdout.
+// The command is invoked through the same handler as the slash command, with the arguments converted to
+// its options:
+//
+//	tf-api-discord check 76561197960287930
+//	tf-api-discord logs matches --steamid=76561197960287930 --format=csv
+//	tf-api-discord bulkcheck 76561197960287930 76561197970669109 --format=json
+//
+// Positional arguments fill the options of the command in order, with the last option taking all the
+// remaining arguments. Options can also be set by name with --name=value. The --format flag selects text,
+// json, or for commands that support exporting, csv and markdown.
+func runCLI(ctx context.Context, api *tfapi.TFAPI, database *store.Store, conf config, args []string) error {
+	cli, errRouter := newCLIRouter(database, conf.Embed)
+	if errRouter != nil {
+		return errRouter
+	}
+
+	screen := newScreener(api, database, cli.session, conf.Embed)
+	if errRegister := registerCommands(ctx, cli, api, database, screen, newAvatarClient(conf.UserAgent)); errRegister != nil {
+		return errRegister
+	}
+
+	cmd, found := cli.commands[args[0]]
+	if !found || !slices.Contains(cliCommands, args[0]) {
+		return fmt.Errorf("%w: %s, expected one of: %s", errCLICommand, args[0], strings.Join(cliCommands, ", "))
+	}
+
+	interaction, format, errArgs := cliInteraction(cmd.definition, args[1:])
+	if errArgs != nil {
+		return errArgs
+	}
+
+	resp, errHandler := cmd.handler(withEmbedLinks(ctx, conf.Embed), cli.session, interaction)
+	if errHandler != nil {
+		return errHandler
+	}
+
+	return writeCLIResponse(os.Stdout, resp, format)
+}
+
+// newCLIRouter creates a router that is never connected to discord, so it doesn't need any credentials.
+func newCLIRouter(database *store.Store, links embedConfig) (*router, error) {
+	session, errSession := discordgo.New("")
+	if errSession != nil {
+		return nil, errSession
+	}
+
+	return &router{
+		session:      session,
+		database:     database,
+		metrics:      newMetrics(),
+		links:        links,
+		commands:     map[string]*command{},
+		autocomplete: map[string]autocompleteHandler{},
+	}, nil
+}
+
+// cliInteraction builds the interaction discord would send for the command, from the command line
+// arguments. It also returns the selected output format.
+func cliInteraction(definition *discordgo.ApplicationCommand, args []string) (*discordgo.InteractionCreate, string, error) {
+	var (
+		format     = formatText
+		named      = map[string]string{}
+		positional []string
+	)
+
+	for i := 0; i < len(args); i++ {
+		name, found := strings.CutPrefix(args[i], "--")
+		if !found {
+			positional = append(positional, args[i])
+
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(name, "=")
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, "", fmt.Errorf("%w: missing value for --%s", errCLIUsage, name)
+			}
+
+			i++
+			value = args[i]
+		}
+
+		if name == "format" {
+			format = value
+		} else {
+			named[name] = value
+		}
+	}
+
+	options := definition.Options
+
+	var subCommand *discordgo.ApplicationCommandOption
+
+	if len(options) > 0 && options[0].Type == discordgo.ApplicationCommandOptionSubCommand {
+		if len(positional) == 0 {
+			return nil, "", fmt.Errorf("%w: %s requires a sub command, one of: %s", errCLIUsage, definition.Name,
+				strings.Join(optionNames(options), ", "))
+		}
+
+		index := slices.IndexFunc(options, func(option *discordgo.ApplicationCommandOption) bool {
+			return option.Name == positional[0]
+		})
+		if index < 0 {
+			return nil, "", fmt.Errorf("%w: unknown sub command %s, expected one of: %s", errCLIUsage, positional[0],
+				strings.Join(optionNames(options), ", "))
+		}
+
+		subCommand = options[index]
+		options = subCommand.Options
+		positional = positional[1:]
+	}
+
+	// Users can't be resolved without discord, and the export option is set by --format instead.
+	options = slices.DeleteFunc(slices.Clone(options), func(option *discordgo.ApplicationCommandOption) bool {
+		return option.Type == discordgo.ApplicationCommandOptionUser || option.Name == exportOptionName
+	})
+
+	for i, option := range options {
+		if len(positional) == 0 {
+			break
+		}
+
+		if _, found := named[option.Name]; found {
+			continue
+		}
+
+		if i == len(options)-1 {
+			named[option.Name] = strings.Join(positional, " ")
+			positional = nil
+		} else {
+			named[option.Name], positional = positional[0], positional[1:]
+		}
+	}
+
+	if len(positional) > 0 {
+		return nil, "", fmt.Errorf("%w: unexpected arguments: %s", errCLIUsage, strings.Join(positional, " "))
+	}
+
+	values, errValues := cliOptions(options, named)
+	if errValues != nil {
+		return nil, "", errValues
+	}
+
+	exportable := slices.ContainsFunc(definition.Options, isExportOption)
+	if subCommand != nil {
+		exportable = slices.ContainsFunc(subCommand.Options, isExportOption)
+	}
+
+	switch {
+	case format == formatText:
+	case exportable && serializers[format].encode != nil:
+		values = append(values, &discordgo.ApplicationCommandInteractionDataOption{
+			Name:  exportOptionName,
+			Type:  discordgo.ApplicationCommandOptionString,
+			Value: format,
+		})
+	case format != "json":
+		return nil, "", fmt.Errorf("%w: unsupported format %s", errCLIUsage, format)
+	}
+
+	if subCommand != nil {
+		values = []*discordgo.ApplicationCommandInteractionDataOption{{
+			Name:    subCommand.Name,
+			Type:    discordgo.ApplicationCommandOptionSubCommand,
+			Options: values,
+		}}
+	}
+
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type: discordgo.InteractionApplicationCommand,
+		Data: discordgo.ApplicationCommandInteractionData{
+			Name:        definition.Name,
+			CommandType: discordgo.ChatApplicationCommand,
+			Options:     values,
+		},
+	}}, format, nil
+}
+
+// cliOptions converts the named values to options of the types discord would send.
+func cliOptions(options []*discordgo.ApplicationCommandOption, named map[string]string) ([]*discordgo.ApplicationCommandInteractionDataOption, error) {
+	var values []*discordgo.ApplicationCommandInteractionDataOption
+
+	for _, option := range options {
+		raw, found := named[option.Name]
+		if !found {
+			if option.Required {
+				return nil, fmt.Errorf("%w: missing --%s", errCLIUsage, option.Name)
+			}
+
+			continue
+		}
+
+		delete(named, option.Name)
+
+		var (
+			value    any
+			errValue error
+		)
+
+		// Discord sends all numbers as floats.
+		switch option.Type {
+		case discordgo.ApplicationCommandOptionString:
+			value = raw
+		case discordgo.ApplicationCommandOptionInteger:
+			var parsed int64
+			parsed, errValue = strconv.ParseInt(raw, 10, 64)
+			value = float64(parsed)
+		case discordgo.ApplicationCommandOptionNumber:
+			value, errValue = strconv.ParseFloat(raw, 64)
+		case discordgo.ApplicationCommandOptionBoolean:
+			value, errValue = strconv.ParseBool(raw)
+		default:
+			errValue = errors.ErrUnsupported
+		}
+
+		if errValue != nil {
+			return nil, fmt.Errorf("%w: invalid --%s: %w", errCLIUsage, option.Name, errValue)
+		}
+
+		values = append(values, &discordgo.ApplicationCommandInteractionDataOption{
+			Name:  option.Name,
+			Type:  option.Type,
+			Value: value,
+		})
+	}
+
+	for name := range named {
+		return nil, fmt.Errorf("%w: unknown option --%s", errCLIUsage, name)
+	}
+
+	return values, nil
+}
+
+func isExportOption(option *discordgo.ApplicationCommandOption) bool {
+	return option.Name == exportOptionName
+}
+
+func optionNames(options []*discordgo.ApplicationCommandOption) []string {
+	names := make([]string, len(options))
+	for i, option := range options {
+		names[i] = option.Name
+	}
+
+	return names
+}
+
+// writeCLIResponse writes the exported file when there is one, otherwise the embeds as text or JSON.
+// Other attachments, such as charts, can't be shown and are only listed in the text output.
+func writeCLIResponse(w io.Writer, resp response, format string) error {
+	if format != formatText {
+		for _, file := range resp.files {
+			if file.Name == "" || !strings.HasSuffix(file.Name, "."+serializers[format].extension) {
+				continue
+			}
+
+			_, errCopy := io.Copy(w, file.Reader)
+
+			return errCopy
+		}
+
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(resp.embeds)
+	}
+
+	for i, embed := range resp.embeds {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+
+		renderEmbedText(w, embed)
+	}
+
+	for _, file := range resp.files {
+		fmt.Fprintf(w, "\nAttachment not shown: %s\n", file.Name)
+	}
+
+	return nil
+}
+
+// renderEmbedText writes an embed as plain text, removing the discord markdown.
+func renderEmbedText(w io.Writer, embed *discordgo.MessageEmbed) {
+	fmt.Fprintln(w, embed.Title)
+
+	if embed.URL != "" {
+		fmt.Fprintln(w, embed.URL)
+	}
+
+	if embed.Description != "" {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, plainText(embed.Description))
+	}
+
+	if len(embed.Fields) > 0 {
+		fmt.Fprintln(w)
+	}
+
+	for _, field := range embed.Fields {
+		value := strings.ReplaceAll(plainText(field.Value), "\n", "\n    ")
+		fmt.Fprintf(w, "%s:\n    %s\n", plainText(field.Name), value)
+	}
+
+	if embed.Footer != nil && embed.Footer.Text != "" {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, embed.Footer.Text)
+	}
+}
+
+// plainText removes the markdown used in embeds, keeping the urls of links.
+func plainText(text string) string {
+	text = reMarkdownLink.ReplaceAllString(text, "$1 ($2)")
+
+	return strings.NewReplacer("**", "", "`", "", "__", "", "~~", "").Replace(text)
+}
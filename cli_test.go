@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func testCLIDefinitions() (*discordgo.ApplicationCommand, *discordgo.ApplicationCommand) {
+	check := &discordgo.ApplicationCommand{
+		Name: "bulkcheck",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Name: "site", Type: discordgo.ApplicationCommandOptionString, Required: true},
+			{Name: "user", Type: discordgo.ApplicationCommandOptionUser},
+			{Name: "limit", Type: discordgo.ApplicationCommandOptionInteger},
+			{Name: "players", Type: discordgo.ApplicationCommandOptionString},
+			exportOption(),
+		},
+	}
+
+	logs := &discordgo.ApplicationCommand{
+		Name: "logs",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name: "summary",
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{Name: "steamid", Type: discordgo.ApplicationCommandOptionString},
+				},
+			},
+			{
+				Name: "matches",
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{Name: "steamid", Type: discordgo.ApplicationCommandOptionString},
+					exportOption(),
+				},
+			},
+		},
+	}
+
+	return check, logs
+}
+
+// cliValues flattens the options of the interaction into name=value pairs, including those of a sub command.
+func cliValues(interaction *discordgo.InteractionCreate) map[string]any {
+	values := map[string]any{}
+
+	var add func(options []*discordgo.ApplicationCommandInteractionDataOption)
+
+	add = func(options []*discordgo.ApplicationCommandInteractionDataOption) {
+		for _, option := range options {
+			if option.Type == discordgo.ApplicationCommandOptionSubCommand {
+				values["sub_command"] = option.Name
+				add(option.Options)
+
+				continue
+			}
+
+			values[option.Name] = option.Value
+		}
+	}
+
+	add(interaction.ApplicationCommandData().Options)
+
+	return values
+}
+
+func TestCLIInteraction(t *testing.T) {
+	check, logs := testCLIDefinitions()
+
+	tests := []struct {
+		name       string
+		definition *discordgo.ApplicationCommand
+		args       []string
+		want       map[string]any
+		format     string
+	}{
+		{
+			name:       "positional fill the last option",
+			definition: check,
+			args:       []string{"skial", "5", "76561197960287930", "76561197970669109"},
+			want:       map[string]any{"site": "skial", "limit": 5.0, "players": "76561197960287930 76561197970669109"},
+			format:     formatText,
+		},
+		{
+			name:       "named with a separate value",
+			definition: check,
+			args:       []string{"--site", "skial", "--limit", "5"},
+			want:       map[string]any{"site": "skial", "limit": 5.0},
+			format:     formatText,
+		},
+		{
+			name:       "named with an inline value",
+			definition: check,
+			args:       []string{"--site=skial", "--limit=5"},
+			want:       map[string]any{"site": "skial", "limit": 5.0},
+			format:     formatText,
+		},
+		{
+			name:       "named and positional",
+			definition: check,
+			args:       []string{"76561197960287930", "--site=skial", "--limit=5", "76561197970669109"},
+			want:       map[string]any{"site": "skial", "limit": 5.0, "players": "76561197960287930 76561197970669109"},
+			format:     formatText,
+		},
+		{
+			name:       "export format",
+			definition: check,
+			args:       []string{"skial", "--format=csv"},
+			want:       map[string]any{"site": "skial", exportOptionName: "csv"},
+			format:     "csv",
+		},
+		{
+			name:       "json without export",
+			definition: logs,
+			args:       []string{"summary", "76561197960287930", "--format", "json"},
+			want:       map[string]any{"sub_command": "summary", "steamid": "76561197960287930"},
+			format:     "json",
+		},
+		{
+			name:       "sub command export",
+			definition: logs,
+			args:       []string{"matches", "--steamid=76561197960287930", "--format=markdown"},
+			want:       map[string]any{"sub_command": "matches", "steamid": "76561197960287930", exportOptionName: "markdown"},
+			format:     "markdown",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			interaction, format, errArgs := cliInteraction(test.definition, test.args)
+			if errArgs != nil {
+				t.Fatal(errArgs)
+			}
+
+			if format != test.format {
+				t.Errorf("expected format %s, got %s", test.format, format)
+			}
+
+			if interaction.ApplicationCommandData().Name != test.definition.Name {
+				t.Errorf("expected command %s, got %s", test.definition.Name, interaction.ApplicationCommandData().Name)
+			}
+
+			got := cliValues(interaction)
+			if len(got) != len(test.want) {
+				t.Errorf("expected options %v, got %v", test.want, got)
+			}
+
+			for name, value := range test.want {
+				if got[name] != value {
+					t.Errorf("expected %s to be %v, got %v", name, value, got[name])
+				}
+			}
+		})
+	}
+}
+
+func TestCLIInteractionErrors(t *testing.T) {
+	check, logs := testCLIDefinitions()
+
+	tests := []struct {
+		name       string
+		definition *discordgo.ApplicationCommand
+		args       []string
+		contains   string
+	}{
+		{name: "missing required option", definition: check, args: []string{"--limit=5"}, contains: "missing --site"},
+		{name: "missing value", definition: check, args: []string{"skial", "--limit"}, contains: "missing value for --limit"},
+		{name: "unknown option", definition: check, args: []string{"skial", "--unknown=1"}, contains: "unknown option --unknown"},
+		{name: "invalid value", definition: check, args: []string{"--site=skial", "--limit=many"}, contains: "invalid --limit"},
+		{name: "user option", definition: check, args: []string{"skial", "--user=1"}, contains: "unknown option --user"},
+		{name: "missing sub command", definition: logs, args: []string{}, contains: "requires a sub command"},
+		{name: "unknown sub command", definition: logs, args: []string{"chat"}, contains: "unknown sub command chat"},
+		{name: "too many arguments", definition: logs, args: []string{"summary", "--steamid=1", "2"}, contains: "unexpected arguments: 2"},
+		{name: "export without support", definition: logs, args: []string{"summary", "--format=csv"}, contains: "unsupported format csv"},
+		{name: "unknown format", definition: check, args: []string{"skial", "--format=xml"}, contains: "unsupported format xml"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, errArgs := cliInteraction(test.definition, test.args)
+			if !errors.Is(errArgs, errCLIUsage) {
+				t.Fatalf("expected a usage error, got %v", errArgs)
+			}
+
+			if !strings.Contains(errArgs.Error(), test.contains) {
+				t.Errorf("expected the error to contain %q, got %q", test.contains, errArgs.Error())
+			}
+		})
+	}
+}
+
+func TestWriteCLIResponse(t *testing.T) {
+	resp := response{
+		embeds: []*discordgo.MessageEmbed{{
+			Title:       "[Logs] Matches",
+			URL:         "https://steamcommunity.com/profiles/76561197960287930",
+			Description: "`2025-01-01` [123](https://logs.tf/123) **cp_process**",
+			Fields: []*discordgo.MessageEmbedField{
+				{Name: "Bans", Value: "__2__ on [skial](https://skial.com)\n~~expired~~"},
+			},
+			Footer: &discordgo.MessageEmbedFooter{Text: "tf-api"},
+		}},
+		files: []*discordgo.File{
+			{Name: "dpm.png", Reader: strings.NewReader("png")},
+			{Name: "matches.csv", Reader: strings.NewReader("log_id\n123\n")},
+		},
+	}
+
+	var text bytes.Buffer
+	if err := writeCLIResponse(&text, resp, formatText); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `[Logs] Matches
+https://steamcommunity.com/profiles/76561197960287930
+
+2025-01-01 123 (https://logs.tf/123) cp_process
+
+Bans:
+    2 on skial (https://skial.com)
+    expired
+
+tf-api
+
+Attachment not shown: dpm.png
+
+Attachment not shown: matches.csv
+`
+	if text.String() != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, text.String())
+	}
+
+	var export bytes.Buffer
+	if err := writeCLIResponse(&export, resp, "csv"); err != nil {
+		t.Fatal(err)
+	}
+
+	if export.String() != "log_id\n123\n" {
+		t.Errorf("expected the exported file, got %q", export.String())
+	}
+
+	var encoded bytes.Buffer
+	if err := writeCLIResponse(&encoded, response{embeds: resp.embeds}, "json"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(encoded.String(), `"title": "[Logs] Matches"`) {
+		t.Errorf("expected the embeds as json, got %s", encoded.String())
+	}
+}
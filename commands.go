@@ -2,61 +2,82 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"strconv"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/leighmacdonald/discordgo-lipstick/bot"
 	"github.com/leighmacdonald/steamid/v4/steamid"
+	"github.com/leighmacdonald/tf-api-discord/risk"
+	"github.com/leighmacdonald/tf-api-discord/store"
 	"github.com/leighmacdonald/tf-api-discord/tfapi"
 )
 
-func registerCommands(ctx context.Context, discord *bot.Bot, api *tfapi.TFAPI) error {
-	sites, err := api.MetaSitesWithResponse(ctx)
+func registerCommands(ctx context.Context, discord *router, api *tfapi.TFAPI, database *store.Store, screen *screener, avatars *avatarClient) error {
+	sites, err := api.Sites(ctx)
 	if err != nil {
 		return err
 	}
 
 	var siteNames []*discordgo.ApplicationCommandOptionChoice
-	for _, site := range *sites.JSON200 {
+	for _, site := range sites {
 		siteNames = append(siteNames, &discordgo.ApplicationCommandOptionChoice{
 			Name:  site.Title,
 			Value: site.Name,
 		})
 	}
 
-	defaultCtx := &[]discordgo.InteractionContextType{discordgo.InteractionContextBotDM}
-	//modPerms := int(discordgo.PermissionBanMembers)
-	userPerms := int64(discordgo.PermissionViewChannel)
+	// Players can be selected by either their steam id or the discord user that linked their account.
+	steamIDOption := &discordgo.ApplicationCommandOption{
+		Name:         "steamid",
+		Description:  "SteamID/Profile URL",
+		Type:         discordgo.ApplicationCommandOptionString,
+		Required:     false,
+		Autocomplete: true,
+	}
+
+	userOption := &discordgo.ApplicationCommandOption{
+		Name:        "user",
+		Description: "Discord user with a linked steam account",
+		Type:        discordgo.ApplicationCommandOptionUser,
+		Required:    false,
+	}
 
-	discord.MustRegisterHandler("check", &discordgo.ApplicationCommand{
-		Name:                     "check",
-		Description:              "High level summary about a player",
-		Contexts:                 defaultCtx,
-		DefaultMemberPermissions: &userPerms,
+	discord.registerAutocomplete(steamIDOption.Name, onSteamIDAutocomplete(database))
+
+	discord.mustRegisterResponder(permissionPublic, replyPublic, &discordgo.ApplicationCommand{
+		Name:        "check",
+		Description: "High level summary about a player",
 		Options: []*discordgo.ApplicationCommandOption{
-			{
-				Name:        "steamid",
-				Description: "SteamID/Profile URL",
-				Type:        discordgo.ApplicationCommandOptionString,
-				Required:    true,
-			},
+			steamIDOption,
+			userOption,
+			exportOption(),
 		},
-	}, onCheck(api))
+	}, exportResponder(onCheck(api, database)))
 
-	discord.MustRegisterHandler("bans", &discordgo.ApplicationCommand{
-		Name:                     "bans",
-		Description:              "High level summary about a player",
-		Contexts:                 defaultCtx,
-		DefaultMemberPermissions: &userPerms,
+	discord.mustRegisterResponder(permissionPublic, replyPublic, &discordgo.ApplicationCommand{
+		Name:        "bulkcheck",
+		Description: "High level summary about several players at once",
 		Options: []*discordgo.ApplicationCommandOption{
 			{
-				Name:        "steamid",
-				Description: "SteamID/Profile URL",
+				Name:        "players",
+				Description: "SteamIDs/Profile URLs separated by spaces",
 				Type:        discordgo.ApplicationCommandOptionString,
 				Required:    true,
 			},
+			exportOption(),
+		},
+	}, exportResponder(onBulkCheck(api, database)))
+
+	discord.mustRegisterResponder(permissionPublic, replyPublic, &discordgo.ApplicationCommand{
+		Name:        "bans",
+		Description: "High level summary about a player",
+		Options: []*discordgo.ApplicationCommandOption{
+			steamIDOption,
+			userOption,
 			{
 				Name:        "site",
 				Description: "Limit results to a specific site",
@@ -64,136 +85,526 @@ func registerCommands(ctx context.Context, discord *bot.Bot, api *tfapi.TFAPI) e
 				Choices:     siteNames,
 				Required:    false,
 			},
+			exportOption(),
 		},
-	}, onBans(api))
+	}, exportResponder(onBans(api, database)))
 
-	discord.MustRegisterHandler("stats", &discordgo.ApplicationCommand{
-		Name:                     "stats",
-		Description:              "Stats about the underlying database",
-		Contexts:                 defaultCtx,
-		DefaultMemberPermissions: &userPerms,
+	discord.mustRegister(permissionPublic, replyPublic, &discordgo.ApplicationCommand{
+		Name:        "stats",
+		Description: "Stats about the underlying database",
 	}, onStats(api))
 
+	discord.mustRegister(permissionModerator, replyPublic, &discordgo.ApplicationCommand{
+		Name:        "watch",
+		Description: "Track players and get notified when something changes",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "add",
+				Description: "Add a player to the watchlist",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					steamIDOption,
+					userOption,
+					{
+						Name:        "note",
+						Description: "Why the player is being watched",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    false,
+					},
+				},
+			},
+			{
+				Name:        "remove",
+				Description: "Remove a player from the watchlist",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options:     []*discordgo.ApplicationCommandOption{steamIDOption, userOption},
+			},
+			{
+				Name:        "list",
+				Description: "Show all the watched players",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+			},
+		},
+	}, onWatch(api, database))
+
+	discord.mustRegister(permissionModerator, replyPublic, &discordgo.ApplicationCommand{
+		Name:        "banfeed",
+		Description: "Announce new bans for watched players from selected sites",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "subscribe",
+				Description: "Announce new bans from a site to a channel",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        "site",
+						Description: "Site to announce bans from",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Choices:     siteNames,
+						Required:    true,
+					},
+					{
+						Name:         "channel",
+						Description:  "Channel to post new bans to",
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+						Required:     true,
+					},
+				},
+			},
+			{
+				Name:        "unsubscribe",
+				Description: "Stop announcing bans from a site",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        "site",
+						Description: "Site to stop announcing bans from",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Choices:     siteNames,
+						Required:    true,
+					},
+				},
+			},
+			{
+				Name:        "list",
+				Description: "Show the current site subscriptions",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+			},
+		},
+	}, onBanFeed(database))
+
+	discord.mustRegister(permissionModerator, replyEphemeral, &discordgo.ApplicationCommand{
+		Name:        "chat",
+		Description: "Search the chat logs of a player",
+		Options: []*discordgo.ApplicationCommandOption{
+			steamIDOption,
+			userOption,
+			{
+				Name:        "query",
+				Description: "Only show messages containing the query",
+				Type:        discordgo.ApplicationCommandOptionString,
+				Required:    false,
+			},
+		},
+	}, onChat(api, database))
+
+	discord.mustRegister(permissionModerator, replyEphemeral, &discordgo.ApplicationCommand{
+		Name:        "friends",
+		Description: "Show the steam banned friends of a player",
+		Options:     []*discordgo.ApplicationCommandOption{steamIDOption, userOption},
+	}, onFriends(api, database))
+
+	discord.mustRegister(permissionModerator, replyPublic, &discordgo.ApplicationCommand{
+		Name: commandCheckUser,
+		Type: discordgo.UserApplicationCommand,
+	}, onCheckUser(api, database))
+
+	discord.mustRegisterResponder(permissionPublic, replyPublic, &discordgo.ApplicationCommand{
+		Name: commandCheckMessage,
+		Type: discordgo.MessageApplicationCommand,
+	}, onCheckMessage(api, database))
+
+	minLogID := float64(1)
+
+	discord.mustRegisterResponder(permissionPublic, replyPublic, &discordgo.ApplicationCommand{
+		Name:        "logs",
+		Description: "logs.tf stats and charts for a player",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "summary",
+				Description: "Player card with their overall averages",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options:     []*discordgo.ApplicationCommandOption{steamIDOption, userOption},
+			},
+			{
+				Name:        "matches",
+				Description: "Recent matches with DPM and class playtime charts",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options:     []*discordgo.ApplicationCommandOption{steamIDOption, userOption, exportOption()},
+			},
+			{
+				Name:        "match",
+				Description: "Result of a single match with the damage per round",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        "log_id",
+						Description: "logs.tf match id",
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						MinValue:    &minLogID,
+						Required:    true,
+					},
+				},
+			},
+		},
+	}, exportResponder(onLogs(api, database, avatars)))
+
+	discord.mustRegister(permissionModerator, replyEphemeral, &discordgo.ApplicationCommand{
+		Name:        "alts",
+		Description: "Find likely alternate accounts of a player",
+		Options:     []*discordgo.ApplicationCommandOption{steamIDOption, userOption},
+	}, onAlts(api, database))
+
+	discord.mustRegister(permissionPublic, replyEphemeral, &discordgo.ApplicationCommand{
+		Name:        "link",
+		Description: "Link your discord account to your steam account",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "start",
+				Description: "Start linking a steam account, you will be given a token to prove ownership",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        "steamid",
+						Description: "SteamID/Profile URL of your account",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    true,
+					},
+				},
+			},
+			{
+				Name:        "verify",
+				Description: "Verify the token has been added to your steam profile name or real name",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+			},
+		},
+	}, onLink(api, database, screen))
+
+	discord.mustRegister(permissionPublic, replyEphemeral, &discordgo.ApplicationCommand{
+		Name:        "unlink",
+		Description: "Unlink your steam account",
+	}, onUnlink(database))
+
+	requiredUserOption := &discordgo.ApplicationCommandOption{
+		Name:        "user",
+		Description: "Discord user",
+		Type:        discordgo.ApplicationCommandOptionUser,
+		Required:    true,
+	}
+
+	discord.mustRegister(permissionAdmin, replyEphemeral, &discordgo.ApplicationCommand{
+		Name:        "accounts",
+		Description: "Manage the steam accounts linked to discord users",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "set",
+				Description: "Link a user to a steam account without verification",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					requiredUserOption,
+					{
+						Name:        "steamid",
+						Description: "SteamID/Profile URL",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    true,
+					},
+				},
+			},
+			{
+				Name:        "remove",
+				Description: "Remove the linked account of a user",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options:     []*discordgo.ApplicationCommandOption{requiredUserOption},
+			},
+			{
+				Name:        "show",
+				Description: "Show the linked account of a user",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options:     []*discordgo.ApplicationCommandOption{requiredUserOption},
+			},
+		},
+	}, onAccounts(database))
+
+	discord.mustRegisterResponder(permissionAdmin, replyEphemeral, &discordgo.ApplicationCommand{
+		Name:        "audit",
+		Description: "Search the log of commands used in this server",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "user",
+				Description: "Only show commands used by this user",
+				Type:        discordgo.ApplicationCommandOptionUser,
+				Required:    false,
+			},
+			{
+				Name:        "target",
+				Description: "Only show commands that looked up this SteamID/Profile URL",
+				Type:        discordgo.ApplicationCommandOptionString,
+				Required:    false,
+			},
+			{
+				Name:        "since",
+				Description: "Only show commands used on or after this date (YYYY-MM-DD)",
+				Type:        discordgo.ApplicationCommandOptionString,
+				Required:    false,
+			},
+			{
+				Name:        "until",
+				Description: "Only show commands used on or before this date (YYYY-MM-DD)",
+				Type:        discordgo.ApplicationCommandOptionString,
+				Required:    false,
+			},
+			exportOption(),
+		},
+	}, exportResponder(onAudit(database)))
+
+	// Registered last so that it can list all the other commands.
+	discord.mustRegister(permissionAdmin, replyEphemeral, configCommand(siteNames, discord.commandNames()), onConfig(database))
+
 	return nil
 }
 
+// subCommand returns the name and the flattened options of the sub command that was invoked.
+func subCommand(interaction *discordgo.InteractionCreate) (string, bot.CommandOptions) {
+	options := interaction.ApplicationCommandData().Options
+	if len(options) == 0 || options[0].Type != discordgo.ApplicationCommandOptionSubCommand {
+		return "", bot.CommandOptions{}
+	}
+
+	return options[0].Name, bot.OptionMap(options[0].Options)
+}
+
+func optionBool(opts bot.CommandOptions, key string) bool {
+	root, found := opts[key]
+	if !found {
+		return false
+	}
+
+	value, ok := root.Value.(bool)
+
+	return ok && value
+}
+
+// interactionUserID returns the id of the user who triggered the interaction. Member is only
+// set for interactions within a guild, otherwise User is set instead.
+func interactionUserID(interaction *discordgo.InteractionCreate) string {
+	if interaction.Member != nil && interaction.Member.User != nil {
+		return interaction.Member.User.ID
+	}
+
+	if interaction.User != nil {
+		return interaction.User.ID
+	}
+
+	return ""
+}
+
 func onStats(api *tfapi.TFAPI) bot.Handler {
 	return func(ctx context.Context, session *discordgo.Session, interaction *discordgo.InteractionCreate) (*discordgo.MessageEmbed, error) {
-		resp, errResp := api.StatsIdWithResponse(ctx)
-		if errResp != nil {
-			return nil, errResp
+		stats, errStats := api.Stats(ctx)
+		if errStats != nil {
+			return nil, errStats
 		}
 
-		stats := *resp.JSON200
+		embed := newEmbed(ctx, "[Stats] Overall")
 
-		embed := &discordgo.MessageEmbed{
-			//Type: discordgo.EmbedTypeArticle,
-			Title: "[Stats] Overall",
-			Provider: &discordgo.MessageEmbedProvider{
-				URL:  "https://tf-api.roto.lol",
-				Name: "tf-api",
-			},
-			Timestamp: time.Now().Format(time.RFC3339),
-		}
+		embed.addFieldInline("Ban Total Count", strconv.Itoa(stats.BanTotal))
+		embed.addFieldInline("Bot Detector Lists", strconv.Itoa(stats.BotDetectorLists))
+		embed.addFieldInline("Bot Detector Entries", strconv.Itoa(stats.BotDetectorEntries))
 
-		addFieldInline(embed, "Ban Total Count", strconv.Itoa(int(stats.BanTotalCount)))
-		addFieldInline(embed, "Bot Detector Lists", strconv.Itoa(int(stats.BdListCount)))
-		addFieldInline(embed, "Bot Detector Entries", strconv.Itoa(int(stats.BdListEntriesCount)))
+		embed.addFieldInline("Vac Counts", strconv.Itoa(stats.VACBans))
+		embed.addFieldInline("Game Ban Counts", strconv.Itoa(stats.GameBans))
+		embed.addFieldInline("Comm Ban Counts", strconv.Itoa(stats.CommunityBans))
 
-		addFieldInline(embed, "Vac Counts", strconv.Itoa(int(stats.VacCount)))
-		addFieldInline(embed, "Game Ban Counts", strconv.Itoa(int(stats.GameBanCount)))
-		addFieldInline(embed, "Comm Ban Counts", strconv.Itoa(int(stats.CommunityBanCount)))
+		embed.addFieldInline("LogsTF Logs", strconv.Itoa(stats.LogsTFLogs))
+		embed.addFieldInline("LogsTF Players", strconv.Itoa(stats.LogsTFPlayers))
+		embed.addFieldInline("LogsTF Messages", strconv.Itoa(stats.LogsTFMessages))
 
-		addFieldInline(embed, "LogsTF Logs", strconv.Itoa(int(stats.LogsTfCount)))
-		addFieldInline(embed, "LogsTF Players", strconv.Itoa(int(stats.LogsTfPlayerCount)))
-		addFieldInline(embed, "LogsTF Messages", strconv.Itoa(int(stats.LogsTfChatCount)))
+		embed.addFieldInline("Sources (Sourcebans)", strconv.Itoa(stats.Sources))
+		embed.addFieldInline("Sources (Leagues)", strconv.Itoa(stats.Leagues))
+		embed.addFieldInline("League Teams", strconv.Itoa(stats.LeagueTeams))
 
-		addFieldInline(embed, "Sources (Sourcebans)", strconv.Itoa(int(stats.SourceCount)))
-		addFieldInline(embed, "Sources (Leagues)", strconv.Itoa(int(stats.LeaguesCount)))
-		addFieldInline(embed, "League Teams", strconv.Itoa(int(stats.LeaguesTeamCount)))
+		embed.addFieldInline("Names", strconv.Itoa(stats.Names))
+		embed.addFieldInline("Avatars", strconv.Itoa(stats.Avatars))
+		embed.addFieldInline("Friends", strconv.Itoa(stats.Friends))
 
-		addFieldInline(embed, "Names", strconv.Itoa(int(stats.NameCount)))
-		addFieldInline(embed, "Avatars", strconv.Itoa(int(stats.AvatarCount)))
-		addFieldInline(embed, "Friends", strconv.Itoa(int(stats.FriendCount)))
+		return embed.build(), nil
+	}
+}
 
-		return embed, nil
+// recordLookup adds the player to the users lookup history so that it can be suggested when autocompleting
+// steam ids. Failing to record the lookup does not fail the command.
+func recordLookup(ctx context.Context, database *store.Store, interaction *discordgo.InteractionCreate,
+	steamID steamid.SteamID, personaName string,
+) {
+	addAuditTarget(ctx, steamID)
+
+	if err := database.AddLookup(ctx, &store.Lookup{
+		GuildID:     interaction.GuildID,
+		UserID:      interactionUserID(interaction),
+		Command:     interaction.ApplicationCommandData().Name,
+		SteamID:     steamID,
+		PersonaName: personaName,
+	}); err != nil {
+		slog.Error("Failed to record lookup", slog.String("error", err.Error()))
 	}
 }
 
-func onCheck(api *tfapi.TFAPI) bot.Handler {
-	return func(ctx context.Context, session *discordgo.Session, interaction *discordgo.InteractionCreate) (*discordgo.MessageEmbed, error) {
+func onCheck(api *tfapi.TFAPI, database *store.Store) reporter {
+	return func(ctx context.Context, _ *discordgo.Session, interaction *discordgo.InteractionCreate) (response, report, error) {
 		opts := bot.OptionMap(interaction.ApplicationCommandData().Options)
 
-		playerID, errPlayerID := steamid.Resolve(ctx, opts.String("steamid"))
-		if errPlayerID != nil || !playerID.Valid() {
-			return nil, steamid.ErrInvalidSID
+		playerID, errPlayerID := resolvePlayer(ctx, database, interaction, opts)
+		if errPlayerID != nil {
+			return response{}, report{}, errPlayerID
 		}
 
-		resp, errResp := api.MetaProfileWithResponse(ctx, &tfapi.MetaProfileParams{
-			Steamids: playerID.String(),
-		})
-		if errResp != nil {
-			return nil, errResp
+		profile, errProfile := api.Profile(ctx, playerID)
+		if errProfile != nil {
+			return response{}, report{}, errors.Join(errProfile, bot.ErrCommandExec)
 		}
 
-		profiles := *resp.JSON200
-		if len(profiles) != 1 {
-			return nil, fmt.Errorf("%w: Invalid response count", bot.ErrCommandExec)
-		}
-		profile := profiles[0]
-
-		embed := &discordgo.MessageEmbed{
-			URL: "https://steamcommunity.com/profiles/" + profile.SteamId,
-			//Type: discordgo.EmbedTypeArticle,
-			Title: "[Check] " + profile.PersonaName,
-			Thumbnail: &discordgo.MessageEmbedThumbnail{
-				URL:    NewAvatar(profile.AvatarHash).Medium(),
-				Width:  64,
-				Height: 64,
-			},
-			Provider: &discordgo.MessageEmbedProvider{
-				URL:  "https://tf-api.roto.lol",
-				Name: "tf-api",
-			},
-			Timestamp: time.Now().Format(time.RFC3339),
-		}
+		recordLookup(ctx, database, interaction, profile.SteamID, profile.PersonaName)
 
-		addFieldInline(embed, "SteamID", profile.SteamId)
-		addFieldInline(embed, "Name", profile.PersonaName)
-		addFieldInline(embed, "Real Name", profile.RealName)
-		addFieldInline(embed, "Account Created", time.Unix(profile.TimeCreated, 0).Format(time.DateOnly))
-		addFieldInline(embed, "Community Ban", strconv.FormatBool(profile.CommunityBanned))
-		addFieldInline(embed, "Econ Ban", profile.EconomyBan)
-		addFieldInline(embed, "Vac Bans", strconv.Itoa(int(profile.NumberOfVacBans)))
-		addFieldInline(embed, "Sourcebans", strconv.Itoa(len(profile.Bans)))
-		addFieldInline(embed, "Comp Teams", strconv.Itoa(len(profile.CompetitiveTeams)))
+		result := checkResult{Profile: profile, Assessment: assessPlayer(ctx, api, guildSettings(ctx), profile)}
 
-		return embed, nil
+		return response{embeds: []*discordgo.MessageEmbed{checkEmbed(ctx, result.Profile, result.Assessment)}},
+			checkReport("check-"+profile.SteamID.String(), "Check "+profile.PersonaName, result), nil
 	}
 }
 
-func onBans(api *tfapi.TFAPI) bot.Handler {
-	return func(ctx context.Context, session *discordgo.Session, interaction *discordgo.InteractionCreate) (*discordgo.MessageEmbed, error) {
+// checkResult is a single player from /check or a bulk check, as exported.
+type checkResult struct {
+	Profile    tfapi.Profile
+	Assessment risk.Assessment
+}
+
+// checkReport flattens the results into one row per player.
+func checkReport(name string, title string, results ...checkResult) report {
+	rows := make([][]string, len(results))
+	for i, result := range results {
+		profile := result.Profile
+		rows[i] = []string{
+			profile.SteamID.String(),
+			profile.PersonaName,
+			profile.RealName,
+			exportTime(profile.TimeCreated),
+			strconv.Itoa(profile.VACBans),
+			strconv.Itoa(profile.GameBans),
+			strconv.FormatBool(profile.CommunityBanned),
+			string(profile.EconomyBan),
+			strconv.Itoa(len(profile.Bans)),
+			strconv.Itoa(len(profile.CompetitiveTeams)),
+			strconv.Itoa(result.Assessment.Score),
+			result.Assessment.Level(),
+		}
+	}
+
+	return report{
+		name:  name,
+		title: title,
+		data:  results,
+		header: []string{
+			"steam_id", "name", "real_name", "created", "vac_bans", "game_bans", "community_banned",
+			"economy_ban", "sourcebans", "comp_teams", "risk_score", "risk_level",
+		},
+		rows: rows,
+	}
+}
+
+// checkEmbed builds the high level summary of a player.
+func checkEmbed(ctx context.Context, profile tfapi.Profile, assessment risk.Assessment) *discordgo.MessageEmbed {
+	embed := newEmbed(ctx, "[Check] "+profile.PersonaName).setProfile(profile).setStatus(profileStatus(profile))
+
+	embed.addFieldInline("SteamID", profile.SteamID.String())
+	embed.addFieldInline("Name", profile.PersonaName)
+	embed.addFieldInline("Real Name", profile.RealName)
+	embed.addFieldInline("Account Created", formatDate(ctx, profile.TimeCreated))
+	embed.addFieldInline("Community Ban", strconv.FormatBool(profile.CommunityBanned))
+	embed.addFieldInline("Econ Ban", string(profile.EconomyBan))
+	embed.addFieldInline("Vac Bans", strconv.Itoa(profile.VACBans))
+	embed.addFieldInline("Sourcebans", strconv.Itoa(len(profile.Bans)))
+	embed.addFieldInline("Comp Teams", strconv.Itoa(len(profile.CompetitiveTeams)))
+	addRiskFields(embed, assessment)
+
+	return embed.build()
+}
+
+func onBans(api *tfapi.TFAPI, database *store.Store) reporter {
+	return func(ctx context.Context, _ *discordgo.Session, interaction *discordgo.InteractionCreate) (response, report, error) {
 		opts := bot.OptionMap(interaction.ApplicationCommandData().Options)
 
-		playerID, errPlayerID := steamid.Resolve(ctx, opts.String("steamid"))
-		if errPlayerID != nil || !playerID.Valid() {
-			return nil, steamid.ErrInvalidSID
+		playerID, errPlayerID := resolvePlayer(ctx, database, interaction, opts)
+		if errPlayerID != nil {
+			return response{}, report{}, errPlayerID
 		}
 
-		resp, errResp := api.BansSearchWithResponse(ctx, &tfapi.BansSearchParams{Steamids: playerID.String()})
-		if errResp != nil {
-			return nil, errResp
+		site := opts.String("site")
+		if site == "" {
+			site = guildSettings(ctx).DefaultSite
 		}
 
-		bans := *resp.JSON200
-		if len(bans) != 11 {
-			return nil, fmt.Errorf("%w: Invalid response count", bot.ErrCommandExec)
+		bans, errBans := api.Bans(ctx, playerID, site)
+		if errBans != nil {
+			return response{}, report{}, errors.Join(errBans, bot.ErrCommandExec)
 		}
 
-		embed := newEmbed("[Bans] History")
-		embed.URL = "https://steamcommunity.com/profiles/" + playerID.String()
+		var personaName string
+		if len(bans) > 0 {
+			personaName = bans[0].Name
+		}
+
+		recordLookup(ctx, database, interaction, playerID, personaName)
+
+		result := bansReport(playerID, bans)
+
+		embed := newEmbed(ctx, "[Bans] History").setPlayerURL(playerID).setStatus(sourceBansStatus(bans))
+
+		if len(bans) == 0 {
+			embed.setDescription("No bans found")
+
+			return response{embeds: []*discordgo.MessageEmbed{embed.build()}}, result, nil
+		}
+
+		if len(bans) > maxEmbedFields {
+			embed.setDescription(fmt.Sprintf("Showing %d of %d bans", maxEmbedFields, len(bans)))
+		}
+
+		for _, ban := range bans {
+			embed.addFieldInline(ban.SiteName, banDescription(ban, guildSettings(ctx).Location()))
+		}
+
+		return response{embeds: []*discordgo.MessageEmbed{embed.build()}}, result, nil
+	}
+}
+
+// bansReport flattens the bans into one row per ban.
+func bansReport(steamID steamid.SteamID, bans []tfapi.SourceBan) report {
+	rows := make([][]string, len(bans))
+	for i, ban := range bans {
+		rows[i] = []string{
+			ban.SteamID.String(),
+			ban.SiteName,
+			ban.Name,
+			ban.Reason,
+			exportTime(ban.CreatedOn),
+			exportTime(ban.ExpiresOn),
+			strconv.FormatBool(ban.Permanent),
+			strconv.FormatBool(ban.Unbanned),
+		}
+	}
+
+	return report{
+		name:   "bans-" + steamID.String(),
+		title:  "Bans of " + steamID.String(),
+		data:   bans,
+		header: []string{"steam_id", "site", "name", "reason", "created", "expires", "permanent", "unbanned"},
+		rows:   rows,
+	}
+}
+
+func banDescription(ban tfapi.SourceBan, location *time.Location) string {
+	return fmt.Sprintf("%s\nCreated: %s\nExpires: %s", ban.Reason, ban.CreatedOn.In(location).Format(time.DateOnly),
+		banExpiry(ban, location))
+}
 
-		return embed, nil
+func banExpiry(ban tfapi.SourceBan, location *time.Location) string {
+	switch {
+	case ban.Unbanned:
+		return "Unbanned"
+	case ban.Permanent || ban.ExpiresOn.IsZero():
+		return "Permanent"
+	default:
+		return ban.ExpiresOn.In(location).Format(time.DateOnly)
 	}
 }
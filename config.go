@@ -0,0 +1,375 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leighmacdonald/tf-api-discord/tfapi"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultConfigPath = "tf-api-discord.yaml"
+	defaultTFAPIURL   = "https://tf-api.roto.lol"
+	defaultUserAgent  = "tf-api-discord (https://github.com/leighmacdonald/tf-api-discord)"
+
+	discordModeGateway = "gateway"
+	discordModeHTTP    = "http"
+	// redacted replaces secrets when printing the config.
+	redacted = "<redacted>"
+)
+
+var (
+	errConfig     = errors.New("invalid config")
+	errConfigFile = errors.New("failed to read config file")
+)
+
+// config is everything the bot reads at startup. Values are loaded from the YAML config file, then
+// overridden by the env var named in the env tag of each field when it is set.
+type config struct {
+	// UserAgent is sent with the requests to discord and tf-api.
+	UserAgent string         `env:"USER_AGENT" yaml:"user_agent"`
+	Discord   discordConfig  `yaml:"discord"`
+	TFAPI     tfapiConfig    `yaml:"tfapi"`
+	Database  databaseConfig `yaml:"database"`
+	HTTP      httpConfig     `yaml:"http"`
+	Tracing   tracingConfig  `yaml:"tracing"`
+	Embed     embedConfig    `yaml:"embed"`
+	Features  featureConfig  `yaml:"features"`
+}
+
+type discordConfig struct {
+	Token string `env:"DISCORD_TOKEN" secret:"true" yaml:"token"`
+	AppID string `env:"DISCORD_APP_ID"              yaml:"app_id"`
+	// GuildID registers the commands to a single guild when set, which is useful for testing as global
+	// commands take a while to update.
+	GuildID string `env:"DISCORD_GUILD_ID" yaml:"guild_id"`
+	// Mode is either "gateway", which receives interactions over a persistent websocket, or "http", which
+	// receives them with the /interactions endpoint served on http.addr. The http mode has no gateway
+	// events, so member screening is unavailable and autocomplete can't suggest members of the guild.
+	Mode string `env:"DISCORD_MODE" yaml:"mode"`
+	// PublicKey verifies the signatures of the interactions received in the http mode.
+	PublicKey string `env:"DISCORD_PUBLIC_KEY" yaml:"public_key"`
+}
+
+type tfapiConfig struct {
+	URL     string   `env:"TFAPI_URL"     yaml:"url"`
+	Timeout duration `env:"TFAPI_TIMEOUT" yaml:"timeout"`
+	// ProfileCacheTTL is how long fetched profiles are reused, 0 disables the cache.
+	ProfileCacheTTL duration `env:"TFAPI_PROFILE_CACHE_TTL" yaml:"profile_cache_ttl"`
+	// RateLimit is the maximum number of requests per second, 0 is unlimited.
+	RateLimit float64 `env:"TFAPI_RATE_LIMIT" yaml:"rate_limit"`
+}
+
+// profileCacheTTL is the ttl passed to tfapi, which uses the default for 0 rather than disabling the cache.
+func (c tfapiConfig) profileCacheTTL() time.Duration {
+	if c.ProfileCacheTTL.Duration == 0 {
+		return -1
+	}
+
+	return c.ProfileCacheTTL.Duration
+}
+
+type databaseConfig struct {
+	Path string `env:"DATABASE_PATH" yaml:"path"`
+}
+
+type httpConfig struct {
+	// Addr serves the /metrics, /healthz and /readyz endpoints, they are disabled when empty.
+	Addr string `env:"HTTP_ADDR" yaml:"addr"`
+}
+
+type tracingConfig struct {
+	// Exporter is one of "none", "otlp" or "stdout".
+	Exporter string `env:"TRACE_EXPORTER" yaml:"exporter"`
+	// OTLPEndpoint overrides the standard OTEL_EXPORTER_OTLP_ENDPOINT env var when set.
+	OTLPEndpoint string `env:"TRACE_OTLP_ENDPOINT" yaml:"otlp_endpoint"`
+}
+
+// embedConfig controls the links in embeds, so they point at the services the bot is actually using.
+type embedConfig struct {
+	ProviderName string `env:"EMBED_PROVIDER_NAME" yaml:"provider_name"`
+	// ProviderURL defaults to the tf-api url.
+	ProviderURL string `env:"EMBED_PROVIDER_URL" yaml:"provider_url"`
+	// AvatarURL is the base url of the steam avatar CDN.
+	AvatarURL string `env:"EMBED_AVATAR_URL" yaml:"avatar_url"`
+	// ProfileURL is the base url of the steam community profiles.
+	ProfileURL string `env:"EMBED_PROFILE_URL" yaml:"profile_url"`
+}
+
+type featureConfig struct {
+	// Watch polls the watched players for changes.
+	Watch bool `env:"FEATURE_WATCH" yaml:"watch"`
+	// BanFeed announces new bans to the subscribed channels.
+	BanFeed bool `env:"FEATURE_BAN_FEED" yaml:"ban_feed"`
+	// Screening checks members when they join a guild.
+	Screening bool `env:"FEATURE_SCREENING" yaml:"screening"`
+}
+
+// duration is a time.Duration that is written as a string such as "20s" in the config file.
+type duration struct {
+	time.Duration
+}
+
+func (d duration) MarshalYAML() (any, error) {
+	return d.String(), nil
+}
+
+func (d *duration) UnmarshalYAML(node *yaml.Node) error {
+	value, errParse := time.ParseDuration(node.Value)
+	if errParse != nil {
+		return fmt.Errorf("line %d: %w", node.Line, errParse)
+	}
+
+	d.Duration = value
+
+	return nil
+}
+
+func defaultConfig() config {
+	return config{
+		UserAgent: defaultUserAgent,
+		Discord:   discordConfig{Mode: discordModeGateway},
+		TFAPI: tfapiConfig{
+			URL:             defaultTFAPIURL,
+			Timeout:         duration{time.Second * 20},
+			ProfileCacheTTL: duration{tfapi.DefaultProfileCacheTTL},
+		},
+		Database: databaseConfig{Path: defaultDatabasePath},
+		Tracing:  tracingConfig{Exporter: "none"},
+		Embed: embedConfig{
+			ProviderName: "tf-api",
+			AvatarURL:    "https://avatars.akamai.steamstatic.com",
+			ProfileURL:   "https://steamcommunity.com",
+		},
+		Features: featureConfig{Watch: true, BanFeed: true, Screening: true},
+	}
+}
+
+// loadConfig reads the config file at path over the defaults, applies the env var overrides and validates
+// the result. A missing config file is only an error when required is set, so the bot can be configured
+// with env vars alone. The discord credentials are not required when offline.
+func loadConfig(path string, required bool, offline bool) (config, error) {
+	conf := defaultConfig()
+
+	body, errRead := os.ReadFile(path)
+
+	switch {
+	case errRead == nil:
+		if errDecode := yaml.Unmarshal(body, &conf); errDecode != nil {
+			return conf, fmt.Errorf("%w: %s: %w", errConfigFile, path, errDecode)
+		}
+	case errors.Is(errRead, os.ErrNotExist) && !required:
+	default:
+		return conf, fmt.Errorf("%w: %w", errConfigFile, errRead)
+	}
+
+	if errEnv := applyEnv(reflect.ValueOf(&conf).Elem()); errEnv != nil {
+		return conf, errEnv
+	}
+
+	if conf.Embed.ProviderURL == "" {
+		conf.Embed.ProviderURL = conf.TFAPI.URL
+	}
+
+	conf.Embed.AvatarURL = strings.TrimSuffix(conf.Embed.AvatarURL, "/")
+	conf.Embed.ProfileURL = strings.TrimSuffix(conf.Embed.ProfileURL, "/")
+
+	return conf, conf.validate(offline)
+}
+
+// applyEnv sets each field with an env tag from its env var, when the env var is set to a non empty value.
+func applyEnv(value reflect.Value) error {
+	for i := range value.NumField() {
+		field := value.Field(i)
+		fieldType := value.Type().Field(i)
+
+		if fieldType.Type.Kind() == reflect.Struct && fieldType.Type != reflect.TypeFor[duration]() {
+			if err := applyEnv(field); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		name := fieldType.Tag.Get("env")
+
+		// Empty values are treated as unset, so the blank entries of a .env file don't clear the values
+		// from the config file.
+		envValue := os.Getenv(name)
+		if name == "" || envValue == "" {
+			continue
+		}
+
+		if err := setField(field, envValue); err != nil {
+			return fmt.Errorf("%w: %s: %w", errConfig, name, err)
+		}
+	}
+
+	return nil
+}
+
+func setField(field reflect.Value, value string) error {
+	switch field.Interface().(type) {
+	case string:
+		field.SetString(value)
+	case bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+
+		field.SetBool(parsed)
+	case float64:
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+
+		field.SetFloat(parsed)
+	case duration:
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+
+		field.Set(reflect.ValueOf(duration{parsed}))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+
+	return nil
+}
+
+// validate checks the whole config, reporting every problem found at once. The discord section is skipped
+// when offline.
+func (c config) validate(offline bool) error {
+	var errs []error
+
+	invalid := func(key string, env string, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%w: %s (%s) %s", errConfig, key, env, fmt.Sprintf(format, args...)))
+	}
+
+	// The discord section is not used when running commands from the terminal.
+	if !offline {
+		if c.Discord.Token == "" {
+			invalid("discord.token", "DISCORD_TOKEN", "is required")
+		}
+
+		if _, err := strconv.ParseUint(c.Discord.AppID, 10, 64); err != nil {
+			invalid("discord.app_id", "DISCORD_APP_ID", "must be a discord application id")
+		}
+
+		if c.Discord.GuildID != "" {
+			if _, err := strconv.ParseUint(c.Discord.GuildID, 10, 64); err != nil {
+				invalid("discord.guild_id", "DISCORD_GUILD_ID", "must be a discord guild id")
+			}
+		}
+
+		switch c.Discord.Mode {
+		case discordModeGateway:
+		case discordModeHTTP:
+			if _, err := parsePublicKey(c.Discord.PublicKey); err != nil {
+				invalid("discord.public_key", "DISCORD_PUBLIC_KEY", "must be the hex encoded public key of the application")
+			}
+
+			if c.HTTP.Addr == "" {
+				invalid("http.addr", "HTTP_ADDR", "is required to serve interactions in the http mode")
+			}
+		default:
+			invalid("discord.mode", "DISCORD_MODE", "must be one of gateway or http, got %q", c.Discord.Mode)
+		}
+	}
+
+	if !validURL(c.TFAPI.URL) {
+		invalid("tfapi.url", "TFAPI_URL", "must be a http(s) url, got %q", c.TFAPI.URL)
+	}
+
+	if c.TFAPI.Timeout.Duration <= 0 {
+		invalid("tfapi.timeout", "TFAPI_TIMEOUT", "must be greater than 0")
+	}
+
+	if c.TFAPI.ProfileCacheTTL.Duration < 0 {
+		invalid("tfapi.profile_cache_ttl", "TFAPI_PROFILE_CACHE_TTL", "must not be negative")
+	}
+
+	if c.TFAPI.RateLimit < 0 {
+		invalid("tfapi.rate_limit", "TFAPI_RATE_LIMIT", "must not be negative")
+	}
+
+	if c.Database.Path == "" {
+		invalid("database.path", "DATABASE_PATH", "is required")
+	}
+
+	if !slices.Contains([]string{"", "none", "otlp", "stdout"}, c.Tracing.Exporter) {
+		invalid("tracing.exporter", "TRACE_EXPORTER", "must be one of none, otlp or stdout, got %q", c.Tracing.Exporter)
+	}
+
+	if c.Tracing.OTLPEndpoint != "" && !validURL(c.Tracing.OTLPEndpoint) {
+		invalid("tracing.otlp_endpoint", "TRACE_OTLP_ENDPOINT", "must be a http(s) url, got %q", c.Tracing.OTLPEndpoint)
+	}
+
+	if c.UserAgent == "" {
+		invalid("user_agent", "USER_AGENT", "is required")
+	}
+
+	if !validURL(c.Embed.ProviderURL) {
+		invalid("embed.provider_url", "EMBED_PROVIDER_URL", "must be a http(s) url, got %q", c.Embed.ProviderURL)
+	}
+
+	if !validURL(c.Embed.AvatarURL) {
+		invalid("embed.avatar_url", "EMBED_AVATAR_URL", "must be a http(s) url, got %q", c.Embed.AvatarURL)
+	}
+
+	if !validURL(c.Embed.ProfileURL) {
+		invalid("embed.profile_url", "EMBED_PROFILE_URL", "must be a http(s) url, got %q", c.Embed.ProfileURL)
+	}
+
+	return errors.Join(errs...)
+}
+
+func validURL(value string) bool {
+	parsed, errParse := url.Parse(value)
+
+	return errParse == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// redact returns a copy of the config with the secrets replaced, for printing.
+func (c config) redact() config {
+	redactFields(reflect.ValueOf(&c).Elem())
+
+	return c
+}
+
+func redactFields(value reflect.Value) {
+	for i := range value.NumField() {
+		field := value.Field(i)
+		fieldType := value.Type().Field(i)
+
+		switch {
+		case fieldType.Type.Kind() == reflect.Struct:
+			redactFields(field)
+		case fieldType.Tag.Get("secret") == "true" && field.String() != "":
+			field.SetString(redacted)
+		}
+	}
+}
+
+// print writes the config as YAML with the secrets redacted.
+func (c config) print() error {
+	encoder := yaml.NewEncoder(os.Stdout)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(c.redact()); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
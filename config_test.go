@@ -0,0 +1,36 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigEnv(t *testing.T) {
+	t.Setenv("DISCORD_MODE", "")
+	t.Setenv("HTTP_ADDR", "")
+	t.Setenv("DATABASE_PATH", "test.db")
+	t.Setenv("TFAPI_PROFILE_CACHE_TTL", "0s")
+
+	conf, errConfig := loadConfig(filepath.Join(t.TempDir(), "missing.yaml"), false, true)
+	if errConfig != nil {
+		t.Fatal(errConfig)
+	}
+
+	if conf.Discord.Mode != defaultConfig().Discord.Mode {
+		t.Errorf("expected an empty env var to keep the default, got %q", conf.Discord.Mode)
+	}
+
+	if conf.Database.Path != "test.db" {
+		t.Errorf("expected the env var to override the default, got %q", conf.Database.Path)
+	}
+
+	if conf.TFAPI.profileCacheTTL() >= 0 {
+		t.Errorf("expected a ttl of 0 to disable the cache, got %s", conf.TFAPI.profileCacheTTL())
+	}
+
+	conf.TFAPI.ProfileCacheTTL = duration{time.Minute}
+	if conf.TFAPI.profileCacheTTL() != time.Minute {
+		t.Errorf("expected the configured ttl, got %s", conf.TFAPI.profileCacheTTL())
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leighmacdonald/discordgo-lipstick/bot"
+	"github.com/leighmacdonald/steamid/v4/extra"
+	"github.com/leighmacdonald/steamid/v4/steamid"
+	"github.com/leighmacdonald/tf-api-discord/store"
+	"github.com/leighmacdonald/tf-api-discord/tfapi"
+)
+
+const (
+	// maxEmbeds is the maximum number of embeds discord allows in a single message.
+	maxEmbeds = 10
+	// maxBulkCheck is the most players checked by a single bulk check. Only maxEmbeds of them can be
+	// shown, the export includes all of them.
+	maxBulkCheck = 200
+)
+
+var (
+	errNotLinked      = errors.New("user has not linked a steam account")
+	errNoSteamIDs     = errors.New("no steam ids found in message")
+	errMissingMessage = fmt.Errorf("%w: missing target message", bot.ErrCommandInvalid)
+
+	reProfileURL = regexp.MustCompile(`steamcommunity\.com/(?:id|profiles)/[^/\s>]+`)
+)
+
+const (
+	commandCheckUser    = "Check TF2 account"
+	commandCheckMessage = "Check SteamIDs in message"
+)
+
+// onCheckUser shows the /check summary for the steam account linked to the target user.
+func onCheckUser(api *tfapi.TFAPI, database *store.Store) bot.Handler {
+	return func(ctx context.Context, _ *discordgo.Session, interaction *discordgo.InteractionCreate) (*discordgo.MessageEmbed, error) {
+		link, errLink := database.AccountLink(ctx, interaction.ApplicationCommandData().TargetID)
+		if errLink != nil {
+			if errors.Is(errLink, store.ErrNoResult) {
+				return nil, errNotLinked
+			}
+
+			return nil, errors.Join(errLink, bot.ErrCommandExec)
+		}
+
+		profile, errProfile := api.Profile(ctx, link.SteamID)
+		if errProfile != nil {
+			return nil, errors.Join(errProfile, bot.ErrCommandExec)
+		}
+
+		recordLookup(ctx, database, interaction, profile.SteamID, profile.PersonaName)
+
+		return checkEmbed(ctx, profile, assessPlayer(ctx, api, guildSettings(ctx), profile)), nil
+	}
+}
+
+// onCheckMessage shows the /check summary for every steam id and profile url found in the target message.
+func onCheckMessage(api *tfapi.TFAPI, database *store.Store) responder {
+	return func(ctx context.Context, _ *discordgo.Session, interaction *discordgo.InteractionCreate) (response, error) {
+		data := interaction.ApplicationCommandData()
+		if data.Resolved == nil || data.Resolved.Messages[data.TargetID] == nil {
+			return response{}, errMissingMessage
+		}
+
+		// Messages can't be exported, so there is no point in checking more players than can be shown.
+		resp, _, err := checkPlayers(ctx, api, database, interaction, data.Resolved.Messages[data.TargetID].Content,
+			maxEmbeds)
+
+		return resp, err
+	}
+}
+
+// onBulkCheck is the slash command version of onCheckMessage, so that the results can be exported.
+func onBulkCheck(api *tfapi.TFAPI, database *store.Store) reporter {
+	return func(ctx context.Context, _ *discordgo.Session, interaction *discordgo.InteractionCreate) (response, report, error) {
+		opts := bot.OptionMap(interaction.ApplicationCommandData().Options)
+
+		return checkPlayers(ctx, api, database, interaction, opts.String("players"), maxBulkCheck)
+	}
+}
+
+// checkPlayers shows the /check summary for up to limit of the steam ids and profile urls found in content.
+// When there are more results than can be shown, the last embed says how many are missing.
+func checkPlayers(ctx context.Context, api *tfapi.TFAPI, database *store.Store, interaction *discordgo.InteractionCreate,
+	content string, limit int,
+) (response, report, error) {
+	steamIDs, truncated := findSteamIDs(ctx, content, limit)
+	if len(steamIDs) == 0 {
+		return response{}, report{}, errNoSteamIDs
+	}
+
+	// When every player checked is shown, the note about the truncation takes the place of the last one, so
+	// there is no point in checking it.
+	if truncated && limit <= maxEmbeds {
+		steamIDs = steamIDs[:min(len(steamIDs), maxEmbeds-1)]
+	}
+
+	profiles, errProfiles := api.Profiles(ctx, steamIDs...)
+	if errProfiles != nil {
+		return response{}, report{}, errors.Join(errProfiles, bot.ErrCommandExec)
+	}
+
+	var (
+		resp    response
+		results []checkResult
+		notes   []string
+	)
+
+	for i, profile := range profiles {
+		// Large checks may not finish in time, the players checked so far are still shown.
+		if ctx.Err() != nil {
+			if i == 0 {
+				return response{}, report{}, errors.Join(ctx.Err(), bot.ErrCommandExec)
+			}
+
+			notes = append(notes, fmt.Sprintf("Timed out after checking %d of %d players.", i, len(profiles)))
+
+			break
+		}
+
+		recordLookup(ctx, database, interaction, profile.SteamID, profile.PersonaName)
+
+		result := checkResult{Profile: profile, Assessment: assessPlayer(ctx, api, guildSettings(ctx), profile)}
+		results = append(results, result)
+		resp.embeds = append(resp.embeds, checkEmbed(ctx, profile, result.Assessment))
+
+		reportProgress(ctx, "Checked %d/%d players", i+1, len(profiles))
+	}
+
+	title := fmt.Sprintf("Check of %d players", len(results))
+
+	if truncated {
+		notes = append(notes, fmt.Sprintf("Only the first %d players found were checked.", len(steamIDs)))
+		title += fmt.Sprintf(", the first %d found", len(steamIDs))
+	}
+
+	if len(resp.embeds) > maxEmbeds || len(notes) > 0 {
+		resp.embeds = resp.embeds[:min(len(resp.embeds), maxEmbeds-1)]
+		if len(resp.embeds) < len(results) {
+			notes = append(notes, fmt.Sprintf("Showing %d of the %d players checked, "+
+				"use /bulkcheck with the export option to get all of them.", len(resp.embeds), len(results)))
+		}
+
+		resp.embeds = append(resp.embeds, newEmbed(ctx, "[Check] Results truncated").
+			setSource(sourceBot, time.Time{}).
+			setDescription(strings.Join(notes, "\n")).
+			build())
+	}
+
+	return resp, checkReport("bulkcheck", title, results...), nil
+}
+
+// findSteamIDs extracts up to limit unique steam ids from free form text, and whether there were more.
+// Profile urls are resolved the same way as the steamid option, while bare ids in any of the steam formats
+// are parsed directly.
+func findSteamIDs(ctx context.Context, content string, limit int) ([]steamid.SteamID, bool) {
+	var (
+		steamIDs  []steamid.SteamID
+		seen      = map[steamid.SteamID]bool{}
+		truncated bool
+	)
+
+	add := func(steamID steamid.SteamID) {
+		if !steamID.Valid() || seen[steamID] {
+			return
+		}
+
+		if len(steamIDs) == limit {
+			truncated = true
+
+			return
+		}
+
+		seen[steamID] = true
+		steamIDs = append(steamIDs, steamID)
+	}
+
+	for _, profileURL := range reProfileURL.FindAllString(content, -1) {
+		// Vanity urls past the limit are not resolved, as that requires a request for each of them. Profile
+		// urls are parsed directly, so those of players already found don't count as more.
+		if len(steamIDs) == limit && strings.Contains(profileURL, "/id/") {
+			truncated = true
+
+			continue
+		}
+
+		if steamID, errResolve := resolveSteamID(ctx, profileURL); errResolve == nil {
+			add(steamID)
+		}
+	}
+
+	for _, steamID := range extra.FindReaderSteamIDs(strings.NewReader(content)) {
+		add(steamID)
+	}
+
+	return steamIDs, truncated
+}
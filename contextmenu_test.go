@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestFindSteamIDs(t *testing.T) {
+	ids := make([]string, 0, 15)
+	for i := range 15 {
+		ids = append(ids, fmt.Sprintf("%d", 76561197960287930+i))
+	}
+
+	// Duplicates are only counted once.
+	content := strings.Join(append(ids, ids[0]), " ")
+
+	tests := []struct {
+		limit     int
+		want      int
+		truncated bool
+	}{
+		{limit: maxEmbeds, want: maxEmbeds, truncated: true},
+		{limit: 15, want: 15, truncated: false},
+		{limit: maxBulkCheck, want: 15, truncated: false},
+	}
+
+	for _, test := range tests {
+		steamIDs, truncated := findSteamIDs(t.Context(), content, test.limit)
+		if len(steamIDs) != test.want || truncated != test.truncated {
+			t.Errorf("limit %d: expected %d ids and truncated %t, got %d and %t", test.limit, test.want,
+				test.truncated, len(steamIDs), truncated)
+		}
+	}
+
+	// Exactly as many players as the limit is not truncated, even when their profile urls are repeated.
+	urls := make([]string, 0, maxEmbeds+1)
+	for _, id := range ids[:maxEmbeds] {
+		urls = append(urls, "https://steamcommunity.com/profiles/"+id)
+	}
+
+	steamIDs, truncated := findSteamIDs(t.Context(), strings.Join(append(urls, urls[0]), "\n"), maxEmbeds)
+	if len(steamIDs) != maxEmbeds || truncated {
+		t.Errorf("expected %d ids without truncation, got %d and %t", maxEmbeds, len(steamIDs), truncated)
+	}
+}
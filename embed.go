@@ -1,71 +1,402 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/leighmacdonald/steamid/v4/steamid"
+	"github.com/leighmacdonald/tf-api-discord/tfapi"
 )
 
 const (
-	avatarURLSmallFormat  = "https://avatars.akamai.steamstatic.com/%s.jpg"
-	avatarURLMediumFormat = "https://avatars.akamai.steamstatic.com/%s_medium.jpg"
-	avatarURLFullFormat   = "https://avatars.akamai.steamstatic.com/%s_full.jpg"
+	// maxEmbedFields is the maximum number of fields discord allows on a single embed.
+	maxEmbedFields = 25
+	// maxEmbedTitle is the maximum length of an embeds title.
+	maxEmbedTitle = 256
+	// maxEmbedDescription is the maximum length of an embeds description.
+	maxEmbedDescription = 4096
+	// maxEmbedFieldName is the maximum length of a fields name.
+	maxEmbedFieldName = 256
+	// maxEmbedFieldValue is the maximum length of a fields value.
+	maxEmbedFieldValue = 1024
+	// maxEmbedTotal is the maximum combined length of the title, description, fields, footer and author.
+	maxEmbedTotal = 6000
+
+	// The avatar formats take the avatar CDN base url and the avatar hash.
+	avatarURLSmallFormat  = "%s/%s.jpg"
+	avatarURLMediumFormat = "%s/%s_medium.jpg"
+	avatarURLFullFormat   = "%s/%s_full.jpg"
 )
 
-func NewAvatar(hash string) Avatar {
-	return Avatar{hash: hash}
+type embedLinksKey struct{}
+
+// withEmbedLinks returns a copy of ctx with the urls used by the embeds built with it.
+func withEmbedLinks(ctx context.Context, links embedConfig) context.Context {
+	return context.WithValue(ctx, embedLinksKey{}, links)
+}
+
+// embedLinksFrom returns the urls set with withEmbedLinks, or the defaults when there are none.
+func embedLinksFrom(ctx context.Context) embedConfig {
+	if links, ok := ctx.Value(embedLinksKey{}).(embedConfig); ok {
+		return links
+	}
+
+	return defaultConfig().Embed
+}
+
+// The sources shown in the footer of embeds.
+const (
+	// sourceAPI is data fetched from tf-api.
+	sourceAPI = "tf-api"
+	// sourceBot is data that only exists in the bots own database, such as the guild config.
+	sourceBot = "tf-api-discord"
+)
+
+func NewAvatar(baseURL string, hash string) Avatar {
+	return Avatar{baseURL: baseURL, hash: hash}
 }
 
 type Avatar struct {
-	hash string
+	baseURL string
+	hash    string
 }
 
 func (h Avatar) Full() string {
-	return fmt.Sprintf(avatarURLFullFormat, h.hash)
+	return fmt.Sprintf(avatarURLFullFormat, h.baseURL, h.hash)
 }
 
 func (h Avatar) Medium() string {
-	return fmt.Sprintf(avatarURLMediumFormat, h.hash)
+	return fmt.Sprintf(avatarURLMediumFormat, h.baseURL, h.hash)
 }
 
 func (h Avatar) Small() string {
-	return fmt.Sprintf(avatarURLSmallFormat, h.hash)
+	return fmt.Sprintf(avatarURLSmallFormat, h.baseURL, h.hash)
 }
 
 func (h Avatar) Hash() string {
 	return h.hash
 }
 
-// func addField(embed *discordgo.MessageEmbed, name string, value string) {
-// 	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
-// 		Name:  name,
-// 		Value: value,
-// 	})
-// }
+// avatar returns the avatar of a player, served from the configured CDN.
+func (c embedConfig) avatar(hash string) Avatar {
+	return NewAvatar(c.AvatarURL, hash)
+}
 
-func addFieldInline(embed *discordgo.MessageEmbed, name string, value string) {
-	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
-		Name:   name,
-		Value:  value,
-		Inline: true,
-	})
+// profileURL returns the steam community profile url of a player.
+func (c embedConfig) profileURL(steamID steamid.SteamID) string {
+	return c.ProfileURL + "/profiles/" + steamID.String()
+}
+
+// status is the state of whatever an embed describes, it determines the color and icon of the embed.
+// Statuses are ordered by severity so the worst of several can be picked with max.
+type status int
+
+const (
+	statusInfo status = iota
+	statusClean
+	statusSuspicious
+	statusBanned
+	statusError
+)
+
+// theme is the set of colors and title icons used for each status.
+type theme struct {
+	colors map[status]int
+	icons  map[status]string
+}
+
+const defaultTheme = "default"
+
+var (
+	defaultColors = map[status]int{
+		statusInfo:       0x5865f2,
+		statusClean:      0x57f287,
+		statusSuspicious: 0xfee75c,
+		statusBanned:     0xed4245,
+		statusError:      0x99aab5,
+	}
+	defaultIcons = map[status]string{
+		statusClean:      "✅",
+		statusSuspicious: "⚠️",
+		statusBanned:     "⛔",
+		statusError:      "❌",
+	}
+
+	themes = map[string]theme{
+		defaultTheme: {colors: defaultColors, icons: defaultIcons},
+		// colorblind uses the Okabe-Ito palette, which stays distinguishable with the common forms of
+		// color blindness.
+		"colorblind": {
+			colors: map[status]int{
+				statusInfo:       0x56b4e9,
+				statusClean:      0x0072b2,
+				statusSuspicious: 0xe69f00,
+				statusBanned:     0xd55e00,
+				statusError:      0x999999,
+			},
+			icons: defaultIcons,
+		},
+		"minimal": {colors: defaultColors, icons: map[status]string{}},
+	}
+)
+
+// themeNames returns the names of all the available themes.
+func themeNames() []string {
+	return slices.Sorted(maps.Keys(themes))
 }
 
-func newEmbed(title string) *discordgo.MessageEmbed {
-	return &discordgo.MessageEmbed{
-		//URL: "https://steamcommunity.com/profiles/" + profile.SteamId,
-		//Type: discordgo.EmbedTypeArticle,
-		Title: title,
-		// Thumbnail: &discordgo.MessageEmbedThumbnail{
-		// 	URL:    NewAvatar(profile.AvatarHash).Medium(),
-		// 	Width:  64,
-		// 	Height: 64,
-		// },
-		Provider: &discordgo.MessageEmbedProvider{
-			URL:  "https://tf-api.roto.lol",
-			Name: "tf-api",
+// guildTheme returns the theme configured for the guild, falling back to the default theme.
+func guildTheme(ctx context.Context) theme {
+	if selected, found := themes[guildSettings(ctx).Theme]; found {
+		return selected
+	}
+
+	return themes[defaultTheme]
+}
+
+// embedBuilder builds embeds with consistent colors, icons and footers. The color and title icon follow
+// the status, and the footer shows where the data came from and how old it is.
+type embedBuilder struct {
+	embed     *discordgo.MessageEmbed
+	links     embedConfig
+	theme     theme
+	title     string
+	status    status
+	source    string
+	fetchedOn time.Time
+}
+
+// newEmbed starts an embed using the theme of the guild and the links in ctx.
+func newEmbed(ctx context.Context, title string) *embedBuilder {
+	links := embedLinksFrom(ctx)
+
+	return &embedBuilder{
+		embed: &discordgo.MessageEmbed{
+			Provider: &discordgo.MessageEmbedProvider{
+				URL:  links.ProviderURL,
+				Name: links.ProviderName,
+			},
+			Timestamp: time.Now().Format(time.RFC3339),
 		},
-		Timestamp: time.Now().Format(time.RFC3339),
+		links:  links,
+		theme:  guildTheme(ctx),
+		title:  title,
+		status: statusInfo,
+		source: sourceAPI,
+	}
+}
+
+func (b *embedBuilder) setStatus(value status) *embedBuilder {
+	b.status = value
+
+	return b
+}
+
+// raiseStatus sets the status if it is more severe than the current status.
+func (b *embedBuilder) raiseStatus(value status) *embedBuilder {
+	b.status = max(b.status, value)
+
+	return b
+}
+
+func (b *embedBuilder) setTitle(title string) *embedBuilder {
+	b.title = title
+
+	return b
+}
+
+func (b *embedBuilder) setURL(url string) *embedBuilder {
+	b.embed.URL = url
+
+	return b
+}
+
+// setPlayerURL links the embed to the steam profile of the player.
+func (b *embedBuilder) setPlayerURL(steamID steamid.SteamID) *embedBuilder {
+	return b.setURL(b.links.profileURL(steamID))
+}
+
+func (b *embedBuilder) setDescription(description string) *embedBuilder {
+	b.embed.Description = truncate(description, maxEmbedDescription)
+
+	return b
+}
+
+func (b *embedBuilder) setAuthor(name string, url string, iconURL string) *embedBuilder {
+	b.embed.Author = &discordgo.MessageEmbedAuthor{Name: truncate(name, maxEmbedFieldName), URL: url, IconURL: iconURL}
+
+	return b
+}
+
+func (b *embedBuilder) setThumbnail(url string) *embedBuilder {
+	b.embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: url, Width: 64, Height: 64}
+
+	return b
+}
+
+// setImage shows a large image below the fields, attached files are referenced as attachment://<name>.
+func (b *embedBuilder) setImage(url string) *embedBuilder {
+	b.embed.Image = &discordgo.MessageEmbedImage{URL: url}
+
+	return b
+}
+
+// setSource sets the footer to show where the data came from. When fetchedOn is set, the age of the
+// data is shown as well so users know when they are looking at cached results.
+func (b *embedBuilder) setSource(source string, fetchedOn time.Time) *embedBuilder {
+	b.source = source
+	b.fetchedOn = fetchedOn
+
+	return b
+}
+
+// setProfile links the embed to the player, with their avatar as the author icon and thumbnail.
+func (b *embedBuilder) setProfile(profile tfapi.Profile) *embedBuilder {
+	avatar := b.links.avatar(profile.AvatarHash)
+
+	return b.setPlayerURL(profile.SteamID).
+		setAuthor(profile.PersonaName, b.links.profileURL(profile.SteamID), avatar.Small()).
+		setThumbnail(avatar.Medium()).
+		setSource(sourceAPI, profile.FetchedOn)
+}
+
+// addField adds a field on its own line. Fields past the discord limit are dropped, so callers that may
+// exceed it should say so in the description. Fields that don't fit within the total length of an embed
+// are dropped when it is built, which is shown in the footer.
+func (b *embedBuilder) addField(name string, value string) *embedBuilder {
+	return b.appendField(name, value, false)
+}
+
+// addFieldInline adds a field that is displayed alongside its neighbours.
+func (b *embedBuilder) addFieldInline(name string, value string) *embedBuilder {
+	return b.appendField(name, value, true)
+}
+
+func (b *embedBuilder) appendField(name string, value string, inline bool) *embedBuilder {
+	if len(b.embed.Fields) == maxEmbedFields {
+		return b
+	}
+
+	// Discord rejects fields with empty names or values.
+	b.embed.Fields = append(b.embed.Fields, &discordgo.MessageEmbedField{
+		Name:   truncate(valueOrNone(name), maxEmbedFieldName),
+		Value:  truncate(valueOrNone(value), maxEmbedFieldValue),
+		Inline: inline,
+	})
+
+	return b
+}
+
+func (b *embedBuilder) build() *discordgo.MessageEmbed {
+	title := b.title
+	if icon := b.theme.icons[b.status]; icon != "" {
+		title = icon + " " + title
+	}
+
+	b.embed.Title = truncate(title, maxEmbedTitle)
+	b.embed.Color = b.theme.colors[b.status]
+
+	var details []string
+	if b.source != "" {
+		details = append(details, b.source)
+		if !b.fetchedOn.IsZero() {
+			details = append(details, dataAge(time.Since(b.fetchedOn)))
+		}
+	}
+
+	// Fields are dropped from the end until the embed fits, with the footer saying how many are missing.
+	footer := strings.Join(details, " • ")
+	for dropped := 1; embedLength(b.embed, footer) > maxEmbedTotal && len(b.embed.Fields) > 0; dropped++ {
+		b.embed.Fields = b.embed.Fields[:len(b.embed.Fields)-1]
+		footer = strings.Join(append(slices.Clone(details), fmt.Sprintf("%d fields not shown", dropped)), " • ")
+	}
+
+	if overflow := embedLength(b.embed, footer) - maxEmbedTotal; overflow > 0 {
+		b.embed.Description = truncate(b.embed.Description, max(utf8.RuneCountInString(b.embed.Description)-overflow, 1))
+	}
+
+	if footer != "" {
+		b.embed.Footer = &discordgo.MessageEmbedFooter{Text: footer}
+	}
+
+	return b.embed
+}
+
+// embedLength is the length of the embed as counted by discord towards maxEmbedTotal, with the footer that
+// will be set.
+func embedLength(embed *discordgo.MessageEmbed, footer string) int {
+	length := utf8.RuneCountInString(embed.Title) + utf8.RuneCountInString(embed.Description) +
+		utf8.RuneCountInString(footer)
+
+	if embed.Author != nil {
+		length += utf8.RuneCountInString(embed.Author.Name)
 	}
+
+	for _, field := range embed.Fields {
+		length += utf8.RuneCountInString(field.Name) + utf8.RuneCountInString(field.Value)
+	}
+
+	return length
+}
+
+// dataAge describes how old cached data is.
+func dataAge(age time.Duration) string {
+	switch {
+	case age < time.Minute:
+		return "fetched just now"
+	case age < time.Hour:
+		return fmt.Sprintf("cached %dm ago", int(age.Minutes()))
+	case age < time.Hour*24:
+		return fmt.Sprintf("cached %dh ago", int(age.Hours()))
+	default:
+		return fmt.Sprintf("cached %dd ago", int(age.Hours()/24))
+	}
+}
+
+// truncate shortens text to at most limit characters, marking it with an ellipsis when cut.
+func truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+
+	return string(runes[:limit-1]) + "…"
+}
+
+// sourceBansStatus is banned when any of the bans are still active, and suspicious for players that only
+// have expired bans.
+func sourceBansStatus(bans []tfapi.SourceBan) status {
+	switch {
+	case slices.ContainsFunc(bans, tfapi.SourceBan.Active):
+		return statusBanned
+	case len(bans) > 0:
+		return statusSuspicious
+	default:
+		return statusClean
+	}
+}
+
+// profileStatus summarizes the steam and 3rd party bans of a player.
+func profileStatus(profile tfapi.Profile) status {
+	if profile.VACBans > 0 || profile.GameBans > 0 || profile.CommunityBanned ||
+		profile.EconomyBan == tfapi.EconomyBanBanned {
+		return statusBanned
+	}
+
+	if profile.EconomyBan == tfapi.EconomyBanProbation {
+		return max(statusSuspicious, sourceBansStatus(profile.Bans))
+	}
+
+	return sourceBansStatus(profile.Bans)
+}
+
+// formatDate formats the date using the timezone configured for the guild.
+func formatDate(ctx context.Context, date time.Time) string {
+	return date.In(guildSettings(ctx).Location()).Format(time.DateOnly)
 }
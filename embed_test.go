@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/leighmacdonald/steamid/v4/steamid"
+)
+
+func TestEmbedTotalLength(t *testing.T) {
+	builder := newEmbed(t.Context(), "Title").setDescription(strings.Repeat("d", maxEmbedDescription))
+	for range maxEmbedFields {
+		builder.addField("Name", strings.Repeat("v", maxEmbedFieldValue))
+	}
+
+	embed := builder.build()
+
+	if length := embedLength(embed, embed.Footer.Text); length > maxEmbedTotal {
+		t.Errorf("expected at most %d characters, got %d", maxEmbedTotal, length)
+	}
+
+	if len(embed.Fields) != 1 {
+		t.Errorf("expected the fields past the limit to be dropped, got %d fields", len(embed.Fields))
+	}
+
+	if !strings.HasSuffix(embed.Footer.Text, "24 fields not shown") {
+		t.Errorf("expected the footer to show the dropped fields, got %q", embed.Footer.Text)
+	}
+
+	small := newEmbed(t.Context(), "Title").addField("Name", "Value").build()
+	if len(small.Fields) != 1 || small.Footer.Text != sourceAPI {
+		t.Errorf("expected embeds within the limit to be unchanged, got %+v", small)
+	}
+}
+
+func TestEmbedLinks(t *testing.T) {
+	steamID := steamid.New(76561197960287930)
+
+	if embed := newEmbed(t.Context(), "Title").setPlayerURL(steamID).build(); !strings.HasPrefix(embed.URL,
+		defaultConfig().Embed.ProfileURL) {
+		t.Errorf("expected the default profile url, got %s", embed.URL)
+	}
+
+	links := embedConfig{ProviderName: "test", ProfileURL: "https://profiles.example.com"}
+	embed := newEmbed(withEmbedLinks(t.Context(), links), "Title").setPlayerURL(steamID).build()
+
+	if embed.URL != "https://profiles.example.com/profiles/"+steamID.String() || embed.Provider.Name != "test" {
+		t.Errorf("expected the links from the context, got %s and %s", embed.URL, embed.Provider.Name)
+	}
+}
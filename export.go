@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leighmacdonald/discordgo-lipstick/bot"
+)
+
+// exportOptionName is the option that selects the export format of a command, when present.
+const exportOptionName = "export"
+
+// report is the data behind a commands response, in a form that can be exported.
+type report struct {
+	// name is used as the base file name of the export.
+	name  string
+	title string
+	// data is exported as is for JSON, so it should be the raw tfapi types.
+	data any
+	// header and rows are the flattened table used by the CSV and Markdown exports.
+	header []string
+	rows   [][]string
+}
+
+// serializer encodes a report into a single file format.
+type serializer struct {
+	extension   string
+	contentType string
+	encode      func(report report) ([]byte, error)
+}
+
+// serializers are all the export formats, keyed by the option value. Any command that produces a report
+// supports every format listed here.
+var serializers = map[string]serializer{
+	"json":     {extension: "json", contentType: "application/json", encode: reportJSON},
+	"csv":      {extension: "csv", contentType: "text/csv", encode: reportCSV},
+	"markdown": {extension: "md", contentType: "text/markdown", encode: reportMarkdown},
+}
+
+// reporter is a command that also returns the data it shows so that it can be exported.
+type reporter func(ctx context.Context, session *discordgo.Session, interaction *discordgo.InteractionCreate) (response, report, error)
+
+// exportOption lets users attach the data behind a response as a file.
+func exportOption() *discordgo.ApplicationCommandOption {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, name := range slices.Sorted(maps.Keys(serializers)) {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: name, Value: name})
+	}
+
+	return &discordgo.ApplicationCommandOption{
+		Name:        exportOptionName,
+		Description: "Attach the results as a file",
+		Type:        discordgo.ApplicationCommandOptionString,
+		Choices:     choices,
+		Required:    false,
+	}
+}
+
+// exportResponder attaches the report in the selected format, if any, to the response of the handler.
+func exportResponder(handler reporter) responder {
+	return func(ctx context.Context, session *discordgo.Session, interaction *discordgo.InteractionCreate) (response, error) {
+		resp, result, errHandler := handler(ctx, session, interaction)
+		if errHandler != nil {
+			return resp, errHandler
+		}
+
+		opts := bot.OptionMap(interaction.ApplicationCommandData().Options)
+		if name, subOpts := subCommand(interaction); name != "" {
+			opts = subOpts
+		}
+
+		format := opts.String(exportOptionName)
+		if format == "" {
+			return resp, nil
+		}
+
+		file, errFile := result.file(format)
+		if errFile != nil {
+			return resp, errFile
+		}
+
+		resp.files = append(resp.files, file)
+
+		return resp, nil
+	}
+}
+
+// file encodes the report using the named format.
+func (r report) file(format string) (*discordgo.File, error) {
+	encoder, found := serializers[format]
+	if !found {
+		return nil, fmt.Errorf("%w: unknown export format: %s", bot.ErrCommandInvalid, format)
+	}
+
+	body, errEncode := encoder.encode(r)
+	if errEncode != nil {
+		return nil, fmt.Errorf("%w: failed to export %s: %w", bot.ErrCommandExec, format, errEncode)
+	}
+
+	return &discordgo.File{
+		Name:        r.name + "." + encoder.extension,
+		ContentType: encoder.contentType,
+		Reader:      bytes.NewReader(body),
+	}, nil
+}
+
+// exportTime formats times in exports as RFC3339 in UTC, leaving unset times empty.
+func exportTime(value time.Time) string {
+	if value.IsZero() {
+		return ""
+	}
+
+	return value.UTC().Format(time.RFC3339)
+}
+
+func reportJSON(r report) ([]byte, error) {
+	return json.MarshalIndent(r.data, "", "  ")
+}
+
+func reportCSV(r report) ([]byte, error) {
+	var buf bytes.Buffer
+
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(r.header); err != nil {
+		return nil, err
+	}
+
+	if err := writer.WriteAll(r.rows); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func reportMarkdown(r report) ([]byte, error) {
+	var buf bytes.Buffer
+
+	cell := strings.NewReplacer("|", `\|`, "\n", " ", "\r", "")
+	writeRow := func(values []string) {
+		buf.WriteString("|")
+
+		for _, value := range values {
+			buf.WriteString(" " + cell.Replace(value) + " |")
+		}
+
+		buf.WriteString("\n")
+	}
+
+	buf.WriteString("# " + r.title + "\n\n")
+
+	if len(r.rows) == 0 {
+		buf.WriteString("No results\n")
+
+		return buf.Bytes(), nil
+	}
+
+	writeRow(r.header)
+
+	divider := make([]string, len(r.header))
+	for i := range divider {
+		divider[i] = "---"
+	}
+
+	writeRow(divider)
+
+	for _, row := range r.rows {
+		writeRow(row)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/leighmacdonald/tf-api-discord/tfapi"
+)
+
+const (
+	healthProbeInterval = time.Minute
+	healthProbeTimeout  = time.Second * 10
+	// readyProbeMaxAge is how long after the last successful tf-api probe the bot is still considered ready.
+	readyProbeMaxAge = time.Minute * 5
+)
+
+// version is set at build time with -ldflags "-X main.version=<version>".
+var version = "dev"
+
+// health tracks the state reported by the /healthz and /readyz endpoints. tf-api is probed in the
+// background so the endpoints can respond without making any requests of their own.
+type health struct {
+	api       *tfapi.TFAPI
+	router    *router
+	startedOn time.Time
+
+	mu           sync.RWMutex
+	probedOn     time.Time
+	probeLatency time.Duration
+	probeErr     error
+}
+
+func newHealth(api *tfapi.TFAPI, router *router) *health {
+	return &health{api: api, router: router, startedOn: time.Now()}
+}
+
+// healthStatus is the JSON body of the health endpoints.
+type healthStatus struct {
+	Status             string      `json:"status"`
+	Version            string      `json:"version"`
+	Uptime             string      `json:"uptime"`
+	UptimeSeconds      int64       `json:"uptime_seconds"`
+	DiscordConnected   bool        `json:"discord_connected"`
+	CommandsRegistered bool        `json:"commands_registered"`
+	TFAPI              tfapiStatus `json:"tfapi"`
+	Commands           []string    `json:"commands"`
+	Problems           []string    `json:"problems,omitempty"`
+}
+
+type tfapiStatus struct {
+	// LastSuccess is empty until the first probe succeeds.
+	LastSuccess string `json:"last_success,omitempty"`
+	LatencyMS   int64  `json:"latency_ms"`
+	Error       string `json:"error,omitempty"`
+}
+
+func (h *health) start(ctx context.Context) {
+	h.probe(ctx)
+
+	ticker := time.NewTicker(healthProbeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			h.probe(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// probe checks that tf-api is responding by fetching the list of sites, one of the cheapest requests.
+func (h *health) probe(ctx context.Context) {
+	probeCtx, cancel := context.WithTimeout(ctx, healthProbeTimeout)
+	defer cancel()
+
+	start := time.Now()
+	_, errProbe := h.api.Sites(probeCtx)
+	elapsed := time.Since(start)
+
+	if errProbe != nil {
+		slog.Warn("tf-api health probe failed", slog.String("error", errProbe.Error()))
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.probeErr = errProbe
+	if errProbe == nil {
+		h.probedOn = start
+		h.probeLatency = elapsed
+	}
+}
+
+func (h *health) status() healthStatus {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	uptime := time.Since(h.startedOn).Truncate(time.Second)
+
+	current := healthStatus{
+		Status:             "ok",
+		Version:            version,
+		Uptime:             uptime.String(),
+		UptimeSeconds:      int64(uptime.Seconds()),
+		DiscordConnected:   h.router.connected.Load(),
+		CommandsRegistered: h.router.registered.Load(),
+		TFAPI:              tfapiStatus{LatencyMS: h.probeLatency.Milliseconds()},
+		Commands:           h.router.commandNames(),
+	}
+
+	if !h.probedOn.IsZero() {
+		current.TFAPI.LastSuccess = h.probedOn.UTC().Format(time.RFC3339)
+	}
+
+	if h.probeErr != nil {
+		current.TFAPI.Error = h.probeErr.Error()
+	}
+
+	if !current.DiscordConnected {
+		current.Problems = append(current.Problems, "discord session is not connected")
+	}
+
+	if !current.CommandsRegistered {
+		current.Problems = append(current.Problems, "commands are not registered")
+	}
+
+	if h.probedOn.IsZero() || time.Since(h.probedOn) > readyProbeMaxAge {
+		current.Problems = append(current.Problems, "tf-api has not responded within "+readyProbeMaxAge.String())
+	}
+
+	return current
+}
+
+// register adds the health endpoints to mux. /healthz responds as long as the process is running, while
+// /readyz fails until the bot is connected to discord and tf-api.
+func (h *health) register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		writeStatus(w, http.StatusOK, h.status())
+	})
+
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, _ *http.Request) {
+		current := h.status()
+		if len(current.Problems) > 0 {
+			current.Status = "unavailable"
+			writeStatus(w, http.StatusServiceUnavailable, current)
+
+			return
+		}
+
+		writeStatus(w, http.StatusOK, current)
+	})
+}
+
+func writeStatus(w http.ResponseWriter, code int, status healthStatus) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	if errEncode := json.NewEncoder(w).Encode(status); errEncode != nil {
+		slog.Error("Failed to write health status", slog.String("error", errEncode.Error()))
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// interactionResponseTimeout is kept below the 3 seconds discord waits for the webhook to respond.
+	interactionResponseTimeout = time.Millisecond * 2800
+	// maxInteractionBody limits the size of the webhook requests read.
+	maxInteractionBody = 1 << 20
+)
+
+var (
+	errInteractionExpired = errors.New("interaction webhook request has already completed")
+	errPublicKey          = errors.New("invalid discord public key")
+)
+
+// parsePublicKey decodes the hex encoded public key shown on the discord developer portal.
+func parsePublicKey(value string) (ed25519.PublicKey, error) {
+	key, errDecode := hex.DecodeString(value)
+	if errDecode != nil || len(key) != ed25519.PublicKeySize {
+		return nil, errPublicKey
+	}
+
+	return key, nil
+}
+
+// serveInteractions registers the commands and adds the interactions endpoint to mux, so interactions
+// are received over HTTP instead of the gateway. The endpoint url must be set to /interactions on the
+// discord developer portal, which discord only accepts once the endpoint is reachable.
+func (r *router) serveInteractions(mux *http.ServeMux, publicKey ed25519.PublicKey) error {
+	if errRegister := r.overwriteCommands(); errRegister != nil {
+		return errRegister
+	}
+
+	mux.HandleFunc("POST /interactions", r.onInteractionRequest(publicKey))
+	r.connected.Store(true)
+
+	return nil
+}
+
+// initialResponse is sent from the interaction handler to the webhook request, which replies with ack once
+// the response has been written.
+type initialResponse struct {
+	response *discordgo.InteractionResponse
+	ack      chan error
+}
+
+// onInteractionRequest handles the webhook requests discord sends for each interaction. Requests that are
+// not signed by discord are rejected. The interaction is handled in the background the same way as those
+// received over the gateway, with the initial response, usually a deferred response, written as the
+// body of the webhook request. Any followups are sent with the REST api once the handler completes.
+func (r *router) onInteractionRequest(publicKey ed25519.PublicKey) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		req.Body = http.MaxBytesReader(w, req.Body, maxInteractionBody)
+
+		if !discordgo.VerifyInteraction(req, publicKey) {
+			http.Error(w, "invalid request signature", http.StatusUnauthorized)
+
+			return
+		}
+
+		var interaction discordgo.InteractionCreate
+		if errDecode := json.NewDecoder(req.Body).Decode(&interaction); errDecode != nil {
+			http.Error(w, "invalid interaction", http.StatusBadRequest)
+
+			return
+		}
+
+		if interaction.Type == discordgo.InteractionPing {
+			writeInteractionResponse(w, &discordgo.InteractionResponse{Type: discordgo.InteractionResponsePong})
+
+			return
+		}
+
+		var (
+			initial = make(chan initialResponse)
+			expired = make(chan struct{})
+			done    = make(chan struct{})
+		)
+
+		defer close(expired)
+
+		go func() {
+			defer close(done)
+
+			r.handleInteraction(r.session, &interaction, func(resp *discordgo.InteractionResponse) error {
+				ack := make(chan error, 1)
+
+				select {
+				case initial <- initialResponse{response: resp, ack: ack}:
+					return <-ack
+				case <-expired:
+					return errInteractionExpired
+				}
+			})
+		}()
+
+		timeout := time.NewTimer(interactionResponseTimeout)
+		defer timeout.Stop()
+
+		select {
+		case resp := <-initial:
+			resp.ack <- writeInteractionResponse(w, resp.response)
+		case <-done:
+			// The interaction is not one the bot handles, such as a command that has since been removed.
+			http.Error(w, "unknown interaction", http.StatusNotFound)
+		case <-timeout.C:
+			slog.Error("Timed out waiting for the initial interaction response", slog.String("id", interaction.ID))
+			http.Error(w, "timed out", http.StatusServiceUnavailable)
+		case <-req.Context().Done():
+		}
+	}
+}
+
+// writeInteractionResponse writes resp as the body of the webhook response, flushing it so discord receives
+// the response before any followups are sent.
+func writeInteractionResponse(w http.ResponseWriter, resp *discordgo.InteractionResponse) error {
+	w.Header().Set("Content-Type", "application/json")
+
+	if errEncode := json.NewEncoder(w).Encode(resp); errEncode != nil {
+		slog.Error("Failed to write interaction response", slog.String("error", errEncode.Error()))
+
+		return errEncode
+	}
+
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+
+	return nil
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leighmacdonald/discordgo-lipstick/bot"
+	"github.com/leighmacdonald/steamid/v4/steamid"
+	"github.com/leighmacdonald/tf-api-discord/store"
+	"github.com/leighmacdonald/tf-api-discord/tfapi"
+)
+
+// linkTokenTTL is how long a user has to place the token on their steam profile.
+const linkTokenTTL = time.Minute * 30
+
+var (
+	errNoLinkToken    = errors.New("no pending link, use /link start first")
+	errLinkExpired    = errors.New("link token has expired, use /link start to get a new one")
+	errLinkUnverified = errors.New("link token was not found in your steam profile name or real name")
+	errLinkedAccount  = errors.New("only moderators can look up the linked accounts of other members")
+)
+
+func onLink(api *tfapi.TFAPI, database *store.Store, screen *screener) bot.Handler {
+	return func(ctx context.Context, _ *discordgo.Session, interaction *discordgo.InteractionCreate) (*discordgo.MessageEmbed, error) {
+		name, opts := subCommand(interaction)
+
+		switch name {
+		case "start":
+			return onLinkStart(ctx, database, interaction, opts)
+		case "verify":
+			return onLinkVerify(ctx, api, database, screen, interaction)
+		default:
+			return nil, fmt.Errorf("%w: unknown sub command: %s", bot.ErrCommandInvalid, name)
+		}
+	}
+}
+
+func onLinkStart(ctx context.Context, database *store.Store, interaction *discordgo.InteractionCreate, opts bot.CommandOptions) (*discordgo.MessageEmbed, error) {
+	playerID, errPlayerID := resolveSteamID(ctx, opts.String("steamid"))
+	if errPlayerID != nil || !playerID.Valid() {
+		return nil, steamid.ErrInvalidSID
+	}
+
+	token := store.LinkToken{
+		UserID:  interactionUserID(interaction),
+		SteamID: playerID,
+		Token:   newLinkToken(),
+	}
+
+	if err := database.SaveLinkToken(ctx, &token); err != nil {
+		return nil, errors.Join(err, bot.ErrCommandExec)
+	}
+
+	embed := newEmbed(ctx, "[Link] Verify ownership of "+playerID.String()).
+		setPlayerURL(playerID).
+		setSource(sourceBot, time.Time{})
+	embed.setDescription(fmt.Sprintf("Add `%s` to your steam profile name or real name, then use `/link verify` "+
+		"within %d minutes. Your profile summary can't be checked, so the token must be in one of the names. "+
+		"You can change your name back once verified.", token.Token, int(linkTokenTTL.Minutes())))
+
+	return embed.build(), nil
+}
+
+func onLinkVerify(ctx context.Context, api *tfapi.TFAPI, database *store.Store, screen *screener, interaction *discordgo.InteractionCreate) (*discordgo.MessageEmbed, error) {
+	userID := interactionUserID(interaction)
+
+	token, errToken := database.LinkToken(ctx, userID)
+	if errToken != nil {
+		if errors.Is(errToken, store.ErrNoResult) {
+			return nil, errNoLinkToken
+		}
+
+		return nil, errors.Join(errToken, bot.ErrCommandExec)
+	}
+
+	if time.Since(token.CreatedOn) > linkTokenTTL {
+		return nil, errLinkExpired
+	}
+
+	summaries, errSummaries := api.SteamSummaries(ctx, token.SteamID)
+	if errSummaries != nil {
+		return nil, errors.Join(errSummaries, bot.ErrCommandExec)
+	}
+
+	// The summary only exposes the persona and real names, so the token can't be verified from the
+	// profile description.
+	if len(summaries) != 1 || !strings.Contains(summaries[0].PersonaName+summaries[0].RealName, token.Token) {
+		return nil, errLinkUnverified
+	}
+
+	link := store.AccountLink{UserID: userID, SteamID: token.SteamID, LinkedBy: userID}
+	if err := database.SaveAccountLink(ctx, &link); err != nil {
+		return nil, errors.Join(err, bot.ErrCommandExec)
+	}
+
+	// Members that joined before linking are screened once their account is known.
+	if interaction.GuildID != "" {
+		screen.screen(ctx, guildSettings(ctx), userID, link.SteamID)
+	}
+
+	links := embedLinksFrom(ctx)
+
+	return newEmbed(ctx, "[Link] Linked "+summaries[0].PersonaName).
+		setPlayerURL(link.SteamID).
+		setAuthor(summaries[0].PersonaName, links.profileURL(link.SteamID), links.avatar(summaries[0].AvatarHash).Small()).
+		setStatus(statusClean).
+		setDescription("Your discord account is now linked to " + link.SteamID.String()).
+		build(), nil
+}
+
+func onUnlink(database *store.Store) bot.Handler {
+	return func(ctx context.Context, _ *discordgo.Session, interaction *discordgo.InteractionCreate) (*discordgo.MessageEmbed, error) {
+		if err := database.RemoveAccountLink(ctx, interactionUserID(interaction)); err != nil {
+			if errors.Is(err, store.ErrNoResult) {
+				return nil, errNotLinked
+			}
+
+			return nil, errors.Join(err, bot.ErrCommandExec)
+		}
+
+		return newEmbed(ctx, "[Link] Unlinked steam account").setSource(sourceBot, time.Time{}).build(), nil
+	}
+}
+
+// onAccounts lets admins view and override the linked account of any user, for example when a user is
+// unable to change their steam name.
+func onAccounts(database *store.Store) bot.Handler {
+	return func(ctx context.Context, _ *discordgo.Session, interaction *discordgo.InteractionCreate) (*discordgo.MessageEmbed, error) {
+		name, opts := subCommand(interaction)
+		userID := opts.String("user")
+
+		switch name {
+		case "set":
+			playerID, errPlayerID := resolveSteamID(ctx, opts.String("steamid"))
+			if errPlayerID != nil || !playerID.Valid() {
+				return nil, steamid.ErrInvalidSID
+			}
+
+			link := store.AccountLink{UserID: userID, SteamID: playerID, LinkedBy: interactionUserID(interaction)}
+			if err := database.SaveAccountLink(ctx, &link); err != nil {
+				return nil, errors.Join(err, bot.ErrCommandExec)
+			}
+
+			return linkEmbed(ctx, "[Accounts] Linked", link), nil
+		case "remove":
+			if err := database.RemoveAccountLink(ctx, userID); err != nil {
+				if errors.Is(err, store.ErrNoResult) {
+					return nil, errNotLinked
+				}
+
+				return nil, errors.Join(err, bot.ErrCommandExec)
+			}
+
+			return newEmbed(ctx, "[Accounts] Unlinked").
+				setSource(sourceBot, time.Time{}).
+				setDescription(fmt.Sprintf("<@%s> is no longer linked to a steam account", userID)).
+				build(), nil
+		case "show":
+			link, errLink := database.AccountLink(ctx, userID)
+			if errLink != nil {
+				if errors.Is(errLink, store.ErrNoResult) {
+					return nil, errNotLinked
+				}
+
+				return nil, errors.Join(errLink, bot.ErrCommandExec)
+			}
+
+			return linkEmbed(ctx, "[Accounts] Linked account", link), nil
+		default:
+			return nil, fmt.Errorf("%w: unknown sub command: %s", bot.ErrCommandInvalid, name)
+		}
+	}
+}
+
+func linkEmbed(ctx context.Context, title string, link store.AccountLink) *discordgo.MessageEmbed {
+	embed := newEmbed(ctx, title).setPlayerURL(link.SteamID).setSource(sourceBot, time.Time{})
+
+	embed.addFieldInline("User", "<@"+link.UserID+">")
+	embed.addFieldInline("SteamID", link.SteamID.String())
+	embed.addFieldInline("Linked By", "<@"+link.LinkedBy+">")
+	embed.addFieldInline("Linked On", formatDate(ctx, link.CreatedOn))
+
+	return embed.build()
+}
+
+func newLinkToken() string {
+	value := make([]byte, 4)
+	_, _ = rand.Read(value)
+
+	return "tfapi-" + hex.EncodeToString(value)
+}
+
+// resolvePlayer returns the player selected by either the steamid or user options. Only moderators may select
+// a user other than themselves, since doing so reveals the steam account they have linked.
+func resolvePlayer(ctx context.Context, database *store.Store, interaction *discordgo.InteractionCreate, opts bot.CommandOptions) (steamid.SteamID, error) {
+	userID := opts.String("user")
+	if userID == "" {
+		playerID, errPlayerID := resolveSteamID(ctx, opts.String("steamid"))
+		if errPlayerID != nil || !playerID.Valid() {
+			return playerID, steamid.ErrInvalidSID
+		}
+
+		addAuditTarget(ctx, playerID)
+
+		return playerID, nil
+	}
+
+	if userID != interactionUserID(interaction) &&
+		memberPermission(guildSettings(ctx), interaction.Member) < permissionModerator {
+		return steamid.SteamID{}, errLinkedAccount
+	}
+
+	link, errLink := database.AccountLink(ctx, userID)
+	if errLink != nil {
+		if errors.Is(errLink, store.ErrNoResult) {
+			return steamid.SteamID{}, errNotLinked
+		}
+
+		return steamid.SteamID{}, errors.Join(errLink, bot.ErrCommandExec)
+	}
+
+	addAuditTarget(ctx, link.SteamID)
+
+	return link.SteamID, nil
+}
+
+// guildLinks returns the account links of the members of a guild. Members are recorded when they join or use
+// a command, and members that haven't done either since are found through the session state. The state only
+// holds every member of small guilds, and is empty without a gateway connection, so the linked accounts of
+// members that haven't been seen by the bot are missing.
+func guildLinks(ctx context.Context, database *store.Store, session *discordgo.Session, guildID string) []store.AccountLink {
+	if guildID == "" {
+		return nil
+	}
+
+	members, errMembers := database.GuildAccountLinks(ctx, guildID)
+	if errMembers != nil {
+		slog.Error("Failed to load guild account links", slog.String("error", errMembers.Error()),
+			slog.String("guild_id", guildID))
+	}
+
+	links, errLinks := database.AccountLinks(ctx)
+	if errLinks != nil {
+		slog.Error("Failed to load account links", slog.String("error", errLinks.Error()))
+
+		return members
+	}
+
+	for _, link := range links {
+		if slices.ContainsFunc(members, func(member store.AccountLink) bool { return member.UserID == link.UserID }) {
+			continue
+		}
+
+		if _, errMember := session.State.Member(guildID, link.UserID); errMember == nil {
+			members = append(members, link)
+		}
+	}
+
+	return members
+}
+
+// recordMember records that the user of a guild interaction is a member of the guild, so guildLinks can find
+// them without the session state.
+func (r *router) recordMember(ctx context.Context, interaction *discordgo.InteractionCreate) {
+	if interaction.GuildID == "" || interaction.Member == nil || interaction.Member.User == nil {
+		return
+	}
+
+	if err := r.database.AddGuildMember(ctx, interaction.GuildID, interaction.Member.User.ID); err != nil {
+		slog.Error("Failed to record guild member", slog.String("error", err.Error()),
+			slog.String("guild_id", interaction.GuildID))
+	}
+}
+
+func (r *router) onGuildMemberAdd(_ *discordgo.Session, event *discordgo.GuildMemberAdd) {
+	if event.User == nil || event.User.Bot {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	if err := r.database.AddGuildMember(ctx, event.GuildID, event.User.ID); err != nil {
+		slog.Error("Failed to record guild member", slog.String("error", err.Error()),
+			slog.String("guild_id", event.GuildID))
+	}
+}
+
+func (r *router) onGuildMemberRemove(_ *discordgo.Session, event *discordgo.GuildMemberRemove) {
+	if event.User == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	if err := r.database.RemoveGuildMember(ctx, event.GuildID, event.User.ID); err != nil {
+		slog.Error("Failed to remove guild member", slog.String("error", err.Error()),
+			slog.String("guild_id", event.GuildID))
+	}
+}
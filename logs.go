@@ -0,0 +1,401 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leighmacdonald/discordgo-lipstick/bot"
+	"github.com/leighmacdonald/steamid/v4/steamid"
+	"github.com/leighmacdonald/tf-api-discord/render"
+	"github.com/leighmacdonald/tf-api-discord/store"
+	"github.com/leighmacdonald/tf-api-discord/tfapi"
+)
+
+const (
+	// logsMaxMatches limits how many of the most recent matches are fetched in full for the charts.
+	logsMaxMatches = 10
+	// avatarTimeout limits how long fetching the avatar shown on a player card can take.
+	avatarTimeout = time.Second * 10
+	// maxAvatarSize limits how much of an avatar is read, steam avatars are well below it.
+	maxAvatarSize = 1 << 20
+	// maxAvatarDimension limits the width and height of an avatar, a small image can still decode to an
+	// enormous number of pixels. The largest steam avatars are 184x184.
+	maxAvatarDimension = 1024
+)
+
+var (
+	errNoAvatar       = errors.New("player has no avatar")
+	errAvatarTooLarge = errors.New("avatar dimensions are too large")
+)
+
+// onLogs handles the /logs sub commands, only the matches sub command offers an export.
+func onLogs(api *tfapi.TFAPI, database *store.Store, avatars *avatarClient) reporter {
+	return func(ctx context.Context, _ *discordgo.Session, interaction *discordgo.InteractionCreate) (response, report, error) {
+		name, opts := subCommand(interaction)
+
+		switch name {
+		case "summary":
+			resp, err := onLogsSummary(ctx, api, database, avatars, interaction, opts)
+
+			return resp, report{}, err
+		case "matches":
+			return onLogsMatches(ctx, api, database, interaction, opts)
+		case "match":
+			resp, err := onLogsMatch(ctx, api, opts)
+
+			return resp, report{}, err
+		default:
+			return response{}, report{}, fmt.Errorf("%w: unknown sub command: %s", bot.ErrCommandInvalid, name)
+		}
+	}
+}
+
+// onLogsSummary shows the players card with their overall logs.tf averages.
+func onLogsSummary(ctx context.Context, api *tfapi.TFAPI, database *store.Store, avatars *avatarClient, interaction *discordgo.InteractionCreate, opts bot.CommandOptions) (response, error) {
+	playerID, errPlayerID := resolvePlayer(ctx, database, interaction, opts)
+	if errPlayerID != nil {
+		return response{}, errPlayerID
+	}
+
+	profile, errProfile := api.Profile(ctx, playerID)
+	if errProfile != nil {
+		return response{}, errors.Join(errProfile, bot.ErrCommandExec)
+	}
+
+	summary, errSummary := api.LogSummary(ctx, playerID)
+	if errSummary != nil {
+		return response{}, errors.Join(errSummary, bot.ErrCommandExec)
+	}
+
+	recordLookup(ctx, database, interaction, profile.SteamID, profile.PersonaName)
+
+	var resp response
+
+	embed := newEmbed(ctx, "[Logs] "+profile.PersonaName).setProfile(profile).setStatus(profileStatus(profile))
+
+	card, errCard := render.PlayerCard(playerCard(ctx, avatars, profile, summary))
+	if errCard != nil {
+		return response{}, errors.Join(errCard, bot.ErrCommandExec)
+	}
+
+	resp.attachImage(embed, "card.png", card)
+	resp.embeds = append(resp.embeds, embed.build())
+
+	return resp, nil
+}
+
+// onLogsMatches lists the players recent matches, with charts of their DPM and class playtime across them.
+func onLogsMatches(ctx context.Context, api *tfapi.TFAPI, database *store.Store, interaction *discordgo.InteractionCreate, opts bot.CommandOptions) (response, report, error) {
+	playerID, errPlayerID := resolvePlayer(ctx, database, interaction, opts)
+	if errPlayerID != nil {
+		return response{}, report{}, errPlayerID
+	}
+
+	matches, errMatches := api.Logs(ctx, playerID)
+	if errMatches != nil {
+		return response{}, report{}, errors.Join(errMatches, bot.ErrCommandExec)
+	}
+
+	result := matchesReport(playerID, matches)
+	embed := newEmbed(ctx, "[Logs] Matches for "+playerID.String()).setPlayerURL(playerID)
+
+	if len(matches) == 0 {
+		embed.setDescription("No logs found")
+
+		return response{embeds: []*discordgo.MessageEmbed{embed.build()}}, result, nil
+	}
+
+	slices.SortFunc(matches, func(a, b tfapi.Match) int {
+		return b.CreatedOn.Compare(a.CreatedOn)
+	})
+
+	recent := matches[:min(len(matches), logsMaxMatches)]
+
+	var (
+		lines     []string
+		dpm       []render.Point
+		playtime  = map[string]float64{}
+		classes   []string
+		resp      response
+		matchName string
+	)
+
+	// Walk oldest to newest so the chart reads left to right.
+	for i, match := range slices.Backward(recent) {
+		reportProgress(ctx, "Fetching logs.tf matches, %d/%d done", len(recent)-1-i, len(recent))
+
+		lines = append(lines, fmt.Sprintf("`%s` [%d](https://logs.tf/%d) %s (%d - %d)", formatDate(ctx, match.CreatedOn),
+			match.LogID, match.LogID, match.Map, match.ScoreRed, match.ScoreBlu))
+
+		detail, errDetail := api.Log(ctx, match.LogID)
+		if errDetail != nil {
+			slog.Error("Failed to fetch log", slog.String("error", errDetail.Error()), slog.Int64("log_id", match.LogID))
+
+			continue
+		}
+
+		index := slices.IndexFunc(detail.Players, func(player tfapi.MatchPlayer) bool {
+			return player.SteamID == playerID
+		})
+		if index < 0 {
+			continue
+		}
+
+		player := detail.Players[index]
+		matchName = cmp.Or(matchName, player.Name)
+		dpm = append(dpm, render.Point{Label: match.CreatedOn.Format("01/02"), Value: float64(player.DPM)})
+
+		for _, class := range player.Classes {
+			if _, found := playtime[class.Class]; !found {
+				classes = append(classes, class.Class)
+			}
+
+			playtime[class.Class] += class.Played.Minutes()
+		}
+	}
+
+	if matchName != "" {
+		embed.setTitle("[Logs] Matches for " + matchName)
+	}
+
+	slices.Reverse(lines)
+	embed.setDescription(fmt.Sprintf("Showing %d of %d matches\n%s", len(recent), len(matches), strings.Join(lines, "\n")))
+
+	if chart, errChart := render.LineChart("DPM over the last matches", dpm); errChart == nil {
+		resp.attachImage(embed, "dpm.png", chart)
+	} else if !errors.Is(errChart, render.ErrNoData) {
+		return response{}, report{}, errors.Join(errChart, bot.ErrCommandExec)
+	}
+
+	resp.embeds = append(resp.embeds, embed.build())
+
+	// Largest share first, ties are broken by name to keep the output stable.
+	slices.SortFunc(classes, func(a, b string) int {
+		return cmp.Or(cmp.Compare(playtime[b], playtime[a]), cmp.Compare(a, b))
+	})
+
+	pie := make([]render.Slice, len(classes))
+	for i, class := range classes {
+		pie[i] = render.Slice{Label: class, Value: playtime[class]}
+	}
+
+	if chart, errChart := render.PieChart("Class playtime", pie); errChart == nil {
+		classEmbed := newEmbed(ctx, "[Logs] Class playtime").setPlayerURL(playerID)
+		resp.attachImage(classEmbed, "classes.png", chart)
+		resp.embeds = append(resp.embeds, classEmbed.build())
+	} else if !errors.Is(errChart, render.ErrNoData) {
+		return response{}, report{}, errors.Join(errChart, bot.ErrCommandExec)
+	}
+
+	return resp, result, nil
+}
+
+// matchesReport flattens the matches into one row per match.
+func matchesReport(steamID steamid.SteamID, matches []tfapi.Match) report {
+	rows := make([][]string, len(matches))
+	for i, match := range matches {
+		rows[i] = []string{
+			strconv.FormatInt(match.LogID, 10),
+			match.Title,
+			match.Map,
+			exportTime(match.CreatedOn),
+			strconv.Itoa(match.ScoreRed),
+			strconv.Itoa(match.ScoreBlu),
+		}
+	}
+
+	return report{
+		name:   "logs-" + steamID.String(),
+		title:  "Matches of " + steamID.String(),
+		data:   matches,
+		header: []string{"log_id", "title", "map", "created", "score_red", "score_blu"},
+		rows:   rows,
+	}
+}
+
+// onLogsMatch shows the result of a single match with a chart of the damage each team did per round.
+func onLogsMatch(ctx context.Context, api *tfapi.TFAPI, opts bot.CommandOptions) (response, error) {
+	logID := opts["log_id"].IntValue()
+
+	detail, errDetail := api.Log(ctx, logID)
+	if errDetail != nil {
+		return response{}, errors.Join(errDetail, bot.ErrCommandExec)
+	}
+
+	embed := newEmbed(ctx, fmt.Sprintf("[Logs] %s", cmp.Or(detail.Title, strconv.FormatInt(logID, 10)))).
+		setURL(fmt.Sprintf("https://logs.tf/%d", logID))
+
+	embed.addFieldInline("Map", detail.Map)
+	embed.addFieldInline("Score", fmt.Sprintf("RED %d - %d BLU", detail.ScoreRed, detail.ScoreBlu))
+	embed.addFieldInline("Played", formatDate(ctx, detail.CreatedOn))
+
+	players := slices.Clone(detail.Players)
+	slices.SortFunc(players, func(a, b tfapi.MatchPlayer) int {
+		return cmp.Or(cmp.Compare(b.DPM, a.DPM), cmp.Compare(a.Name, b.Name))
+	})
+
+	var lines []string
+	for _, player := range players {
+		lines = append(lines, fmt.Sprintf("`%4d` %s (%s) %d/%d/%d", player.DPM, player.Name, player.Team,
+			player.Kills, player.Deaths, player.Assists))
+	}
+
+	embed.addField("Players by DPM", strings.Join(lines, "\n"))
+
+	var resp response
+
+	labels := make([]string, len(detail.Rounds))
+	red := render.Series{Name: "RED", Color: render.ColorRed, Values: make([]float64, len(detail.Rounds))}
+	blu := render.Series{Name: "BLU", Color: render.ColorBlu, Values: make([]float64, len(detail.Rounds))}
+
+	for i, round := range detail.Rounds {
+		labels[i] = "Round " + strconv.Itoa(round.Round)
+		red.Values[i] = float64(round.DamageRed)
+		blu.Values[i] = float64(round.DamageBlu)
+	}
+
+	if chart, errChart := render.BarChart("Damage per round", labels, red, blu); errChart == nil {
+		resp.attachImage(embed, "rounds.png", chart)
+	} else if !errors.Is(errChart, render.ErrNoData) {
+		return response{}, errors.Join(errChart, bot.ErrCommandExec)
+	}
+
+	resp.embeds = append(resp.embeds, embed.build())
+
+	return resp, nil
+}
+
+// playerCard collects the data shown on a players card.
+func playerCard(ctx context.Context, avatars *avatarClient, profile tfapi.Profile, summary tfapi.LogSummary) render.Card {
+	card := render.Card{
+		Name:    profile.PersonaName,
+		SteamID: profile.SteamID.String(),
+		Stats: []render.Stat{
+			{Label: "Logs", Value: strconv.Itoa(summary.Logs)},
+			{Label: "DPM", Value: strconv.FormatFloat(summary.DPMAvg, 'f', 1, 64)},
+			{Label: "DT/M", Value: strconv.FormatFloat(summary.DTMAvg, 'f', 1, 64)},
+			{Label: "K/D", Value: strconv.FormatFloat(summary.KDAvg, 'f', 2, 64)},
+			{Label: "KA/D", Value: strconv.FormatFloat(summary.KADAvg, 'f', 2, 64)},
+			{Label: "Kills", Value: strconv.FormatFloat(summary.KillsAvg, 'f', 1, 64)},
+			{Label: "Assists", Value: strconv.FormatFloat(summary.AssistsAvg, 'f', 1, 64)},
+			{Label: "Deaths", Value: strconv.FormatFloat(summary.DeathsAvg, 'f', 1, 64)},
+		},
+	}
+
+	avatar, errAvatar := avatars.fetch(ctx, profile.AvatarHash)
+	if errAvatar != nil {
+		slog.Warn("Failed to fetch avatar", slog.String("error", errAvatar.Error()),
+			slog.String("steam_id", profile.SteamID.String()))
+	}
+
+	card.Avatar = avatar
+
+	if profile.VACBans > 0 {
+		card.Badges = append(card.Badges, render.Badge{Label: fmt.Sprintf("%d VAC", profile.VACBans), Level: render.BadgeDanger})
+	}
+
+	if profile.GameBans > 0 {
+		card.Badges = append(card.Badges, render.Badge{Label: fmt.Sprintf("%d Game", profile.GameBans), Level: render.BadgeDanger})
+	}
+
+	if profile.CommunityBanned {
+		card.Badges = append(card.Badges, render.Badge{Label: "Community", Level: render.BadgeDanger})
+	}
+
+	switch profile.EconomyBan {
+	case tfapi.EconomyBanBanned:
+		card.Badges = append(card.Badges, render.Badge{Label: "Economy", Level: render.BadgeDanger})
+	case tfapi.EconomyBanProbation:
+		card.Badges = append(card.Badges, render.Badge{Label: "Economy probation", Level: render.BadgeWarning})
+	}
+
+	switch sourceBansStatus(profile.Bans) {
+	case statusBanned:
+		card.Badges = append(card.Badges, render.Badge{Label: "Sourcebans", Level: render.BadgeDanger})
+	case statusSuspicious:
+		card.Badges = append(card.Badges, render.Badge{Label: "Expired bans", Level: render.BadgeWarning})
+	}
+
+	if profile.Visibility != tfapi.VisibilityPublic {
+		card.Badges = append(card.Badges, render.Badge{Label: profile.Visibility.String(), Level: render.BadgeInfo})
+	}
+
+	return card
+}
+
+// avatarClient downloads the steam avatars shown on player cards.
+type avatarClient struct {
+	client    *http.Client
+	userAgent string
+}
+
+func newAvatarClient(userAgent string) *avatarClient {
+	return &avatarClient{client: &http.Client{Timeout: avatarTimeout}, userAgent: userAgent}
+}
+
+// fetch downloads and decodes the medium sized steam avatar.
+func (c *avatarClient) fetch(ctx context.Context, hash string) (image.Image, error) {
+	if hash == "" {
+		return nil, errNoAvatar
+	}
+
+	req, errReq := http.NewRequestWithContext(ctx, http.MethodGet, embedLinksFrom(ctx).avatar(hash).Medium(), nil)
+	if errReq != nil {
+		return nil, errReq
+	}
+
+	req.Header.Set("User-Agent", c.userAgent)
+
+	resp, errResp := c.client.Do(req)
+	if errResp != nil {
+		return nil, errResp
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected avatar status: %d", resp.StatusCode)
+	}
+
+	body, errRead := io.ReadAll(io.LimitReader(resp.Body, maxAvatarSize))
+	if errRead != nil {
+		return nil, errRead
+	}
+
+	config, _, errConfig := image.DecodeConfig(bytes.NewReader(body))
+	if errConfig != nil {
+		return nil, errConfig
+	}
+
+	if config.Width > maxAvatarDimension || config.Height > maxAvatarDimension {
+		return nil, fmt.Errorf("%w: %dx%d", errAvatarTooLarge, config.Width, config.Height)
+	}
+
+	avatar, _, errDecode := image.Decode(bytes.NewReader(body))
+	if errDecode != nil {
+		return nil, errDecode
+	}
+
+	return avatar, nil
+}
+
+// attachImage adds the png to the response and displays it as the image of the embed.
+func (r *response) attachImage(embed *embedBuilder, name string, data []byte) {
+	r.files = append(r.files, &discordgo.File{Name: name, ContentType: "image/png", Reader: bytes.NewReader(data)})
+	embed.setImage("attachment://" + name)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAvatarFetch(t *testing.T) {
+	encode := func(width int, height int) []byte {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+			t.Fatal(err)
+		}
+
+		return buf.Bytes()
+	}
+
+	avatars := map[string][]byte{
+		"/small_medium.jpg": encode(64, 64),
+		// Compresses to a few kilobytes, well within maxAvatarSize.
+		"/huge_medium.jpg": encode(maxAvatarDimension+1, 16),
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.UserAgent() != "tf-api-discord-test" {
+			t.Errorf("expected the configured user agent, got %q", request.UserAgent())
+		}
+
+		avatar, found := avatars[request.URL.Path]
+		if !found {
+			http.NotFound(writer, request)
+
+			return
+		}
+
+		_, _ = writer.Write(avatar)
+	}))
+	defer server.Close()
+
+	ctx := withEmbedLinks(t.Context(), embedConfig{AvatarURL: server.URL})
+	client := newAvatarClient("tf-api-discord-test")
+
+	avatar, errFetch := client.fetch(ctx, "small")
+	if errFetch != nil {
+		t.Fatal(errFetch)
+	}
+
+	if avatar.Bounds().Dx() != 64 {
+		t.Errorf("expected a 64px avatar, got %v", avatar.Bounds())
+	}
+
+	if _, err := client.fetch(ctx, "huge"); !errors.Is(err, errAvatarTooLarge) {
+		t.Errorf("expected errAvatarTooLarge, got %v", err)
+	}
+
+	if _, err := client.fetch(ctx, "missing"); err == nil {
+		t.Error("expected an error for a missing avatar")
+	}
+
+	if _, err := client.fetch(ctx, ""); !errors.Is(err, errNoAvatar) {
+		t.Errorf("expected errNoAvatar, got %v", err)
+	}
+}
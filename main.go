@@ -2,46 +2,155 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	_ "github.com/joho/godotenv/autoload"
 	"github.com/leighmacdonald/discordgo-lipstick/bot"
+	"github.com/leighmacdonald/tf-api-discord/store"
 	"github.com/leighmacdonald/tf-api-discord/tfapi"
 )
 
+const defaultDatabasePath = "tf-api-discord.db"
+
 func run() error {
+	configPath := flag.String("config", defaultConfigPath, "Path to the YAML config file")
+	printConfig := flag.Bool("print-config", false, "Print the loaded config with secrets redacted and exit")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [command [sub command] [args...] [--format=text|json|csv|markdown]]\n\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "Runs the bot, or a single command from the terminal: %s\n\n", strings.Join(cliCommands, ", "))
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	// The default config file is optional, but one passed explicitly must exist.
+	explicitConfig := false
+	flag.Visit(func(f *flag.Flag) { explicitConfig = explicitConfig || f.Name == "config" })
+
+	// Any arguments left are a command to run from the terminal, without connecting to discord.
+	offline := flag.NArg() > 0
+
+	conf, errConfig := loadConfig(*configPath, explicitConfig, offline)
+	if *printConfig {
+		if errPrint := conf.print(); errPrint != nil {
+			return errPrint
+		}
+
+		return errConfig
+	}
+
+	if errConfig != nil {
+		return errConfig
+	}
+
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	api, errAPI := tfapi.New(os.Getenv("TFAPI_URL"), &http.Client{Timeout: time.Second * 20})
+	shutdownTracing, errTracing := setupTracing(ctx, conf.Tracing)
+	if errTracing != nil {
+		return errTracing
+	}
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		defer cancel()
+
+		if errShutdown := shutdownTracing(shutdownCtx); errShutdown != nil {
+			slog.Error("Failed to flush traces", slog.String("error", errShutdown.Error()))
+		}
+	}()
+
+	database, errDatabase := store.Open(ctx, conf.Database.Path)
+	if errDatabase != nil {
+		return errDatabase
+	}
+	defer func() {
+		if errClose := database.Close(); errClose != nil {
+			slog.Error("Failed to close database", slog.String("error", errClose.Error()))
+		}
+	}()
+
+	// Metrics are always collected, but only exposed when a listen address is configured.
+	botMetrics := newMetrics()
+
+	api, errAPI := tfapi.New(conf.TFAPI.URL, &http.Client{Timeout: conf.TFAPI.Timeout.Duration}, tfapi.Options{
+		Observer:        botMetrics,
+		ProfileCacheTTL: conf.TFAPI.profileCacheTTL(),
+		RateLimit:       conf.TFAPI.RateLimit,
+		UserAgent:       conf.UserAgent,
+	})
 	if errAPI != nil {
 		return errAPI
 	}
 
-	bot, errBot := bot.New(bot.Opts{
-		Token:     os.Getenv("DISCORD_TOKEN"),
-		AppID:     os.Getenv("DISCORD_APP_ID"),
-		GuildID:   os.Getenv("DISCORD_GUILD_ID"),
-		UserAgent: "tf-api-discord (https://github.com/leighmacdonald/tf-api-discord)",
-	})
-	if errBot != nil {
-		return errBot
+	if offline {
+		return runCLI(ctx, api, database, conf, flag.Args())
+	}
+
+	discord, errDiscord := newRouter(bot.Opts{
+		Token:     conf.Discord.Token,
+		AppID:     conf.Discord.AppID,
+		GuildID:   conf.Discord.GuildID,
+		UserAgent: conf.UserAgent,
+	}, conf.Embed, database, botMetrics)
+	if errDiscord != nil {
+		return errDiscord
 	}
-	defer bot.Close()
+	defer discord.close()
 
-	if errRegister := registerCommands(ctx, bot, api); errRegister != nil {
+	screen := newScreener(api, database, discord.session, conf.Embed)
+	switch {
+	case conf.Features.Screening && conf.Discord.Mode == discordModeHTTP:
+		slog.Warn("Member screening requires the gateway mode, it is disabled")
+	case conf.Features.Screening:
+		discord.session.AddHandler(screen.onGuildMemberAdd)
+	}
+
+	if errRegister := registerCommands(ctx, discord, api, database, screen, newAvatarClient(conf.UserAgent)); errRegister != nil {
 		return errRegister
 	}
 
-	if errStart := bot.Start(ctx); errStart != nil {
+	status := newHealth(api, discord)
+	go status.start(ctx)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", botMetrics.handler())
+	status.register(mux)
+
+	if conf.Discord.Mode == discordModeHTTP {
+		slog.Warn("Without the gateway, guild members are only known once they use a command, " +
+			"the ban feed and autocomplete won't include the linked accounts of other members")
+
+		publicKey, errKey := parsePublicKey(conf.Discord.PublicKey)
+		if errKey != nil {
+			return errKey
+		}
+
+		if errServe := discord.serveInteractions(mux, publicKey); errServe != nil {
+			return errServe
+		}
+	} else if errStart := discord.start(); errStart != nil {
 		return errStart
 	}
 
+	if conf.HTTP.Addr != "" {
+		go serveHTTP(ctx, conf.HTTP.Addr, mux)
+	}
+
+	if conf.Features.Watch {
+		go newWatcher(api, database, discord.session, conf.Embed).start(ctx)
+	}
+
+	if conf.Features.BanFeed {
+		go newBanFeed(api, database, discord.session, conf.Embed).start(ctx)
+	}
+
 	<-ctx.Done()
 
 	return nil
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leighmacdonald/discordgo-lipstick/bot"
+	"github.com/leighmacdonald/tf-api-discord/tfapi"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const metricsNamespace = "tfapi_discord"
+
+// The outcomes of a command invocation.
+const (
+	outcomeOK       = "ok"
+	outcomeInvalid  = "invalid"
+	outcomeError    = "error"
+	outcomeDenied   = "denied"
+	outcomeDisabled = "disabled"
+)
+
+// metrics collects the prometheus metrics of the bot. It implements tfapi.Observer so that the api client
+// reports its requests here as well.
+type metrics struct {
+	registry        *prometheus.Registry
+	commands        *prometheus.CounterVec
+	commandDuration *prometheus.HistogramVec
+	apiRequests     *prometheus.CounterVec
+	apiDuration     *prometheus.HistogramVec
+	apiRetries      *prometheus.CounterVec
+	apiBreaker      prometheus.Gauge
+	cacheLookups    *prometheus.CounterVec
+	reconnects      prometheus.Counter
+	// connected is set once the first gateway connection was made, every connection after it is a reconnect.
+	connected atomic.Bool
+}
+
+func newMetrics() *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		commands: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "commands_total",
+			Help:      "Command invocations by command, guild and outcome.",
+		}, []string{"command", "guild", "outcome"}),
+		commandDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "command_duration_seconds",
+			Help:      "Time taken by command handlers to respond.",
+			Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+		}, []string{"command"}),
+		apiRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "tfapi_requests_total",
+			Help:      "Requests made to tf-api by endpoint and status, status is 0 when no response was received.",
+		}, []string{"endpoint", "status"}),
+		apiDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "tfapi_request_duration_seconds",
+			Help:      "Latency of requests made to tf-api by endpoint.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"endpoint"}),
+		apiRetries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "tfapi_retries_total",
+			Help:      "Requests to tf-api that were retried after failing.",
+		}, []string{"endpoint"}),
+		apiBreaker: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "tfapi_circuit_breaker_state",
+			Help:      "State of the tf-api circuit breaker, 0 is closed, 1 is half open and 2 is open.",
+		}),
+		cacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "profile_cache_lookups_total",
+			Help:      "Profile cache lookups by result, either hit or miss.",
+		}, []string{"result"}),
+		reconnects: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "gateway_reconnects_total",
+			Help:      "Times the discord gateway connection was re-established.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.commands, m.commandDuration, m.apiRequests, m.apiDuration, m.apiRetries, m.apiBreaker,
+		m.cacheLookups, m.reconnects,
+	)
+
+	return m
+}
+
+// handler serves the metrics in the prometheus text format.
+func (m *metrics) handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// observeCommand records a single command invocation.
+func (m *metrics) observeCommand(name string, guildID string, outcome string, elapsed time.Duration) {
+	m.commands.WithLabelValues(name, guildID, outcome).Inc()
+
+	if elapsed > 0 {
+		m.commandDuration.WithLabelValues(name).Observe(elapsed.Seconds())
+	}
+}
+
+// commandOutcome classifies the error returned by a command handler.
+func commandOutcome(err error) string {
+	switch {
+	case err == nil:
+		return outcomeOK
+	case errors.Is(err, bot.ErrCommandInvalid):
+		return outcomeInvalid
+	default:
+		return outcomeError
+	}
+}
+
+func (m *metrics) onConnect(_ *discordgo.Session, _ *discordgo.Connect) {
+	if m.connected.Swap(true) {
+		m.reconnects.Inc()
+	}
+}
+
+func (m *metrics) ObserveRequest(endpoint string, status int, elapsed time.Duration) {
+	m.apiRequests.WithLabelValues(endpoint, strconv.Itoa(status)).Inc()
+	m.apiDuration.WithLabelValues(endpoint).Observe(elapsed.Seconds())
+}
+
+func (m *metrics) ObserveRetry(endpoint string) {
+	m.apiRetries.WithLabelValues(endpoint).Inc()
+}
+
+func (m *metrics) ObserveBreaker(state tfapi.BreakerState) {
+	m.apiBreaker.Set(float64(state))
+}
+
+func (m *metrics) ObserveCache(hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+
+	m.cacheLookups.WithLabelValues(result).Inc()
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leighmacdonald/discordgo-lipstick/bot"
+	"github.com/leighmacdonald/steamid/v4/steamid"
+	"github.com/leighmacdonald/tf-api-discord/store"
+	"github.com/leighmacdonald/tf-api-discord/tfapi"
+)
+
+func onChat(api *tfapi.TFAPI, database *store.Store) bot.Handler {
+	return func(ctx context.Context, _ *discordgo.Session, interaction *discordgo.InteractionCreate) (*discordgo.MessageEmbed, error) {
+		opts := bot.OptionMap(interaction.ApplicationCommandData().Options)
+
+		playerID, errPlayerID := resolvePlayer(ctx, database, interaction, opts)
+		if errPlayerID != nil {
+			return nil, errPlayerID
+		}
+
+		messages, errMessages := api.ChatSearch(ctx, playerID, opts.String("query"))
+		if errMessages != nil {
+			return nil, errors.Join(errMessages, bot.ErrCommandExec)
+		}
+
+		embed := newEmbed(ctx, "[Chat] Messages").setPlayerURL(playerID)
+
+		if len(messages) == 0 {
+			embed.setDescription("No messages found")
+
+			return embed.build(), nil
+		}
+
+		var builder strings.Builder
+
+		for _, message := range messages {
+			line := fmt.Sprintf("`%s` **%s**: %s\n", formatDate(ctx, message.CreatedOn), message.Name, message.Message)
+			if builder.Len()+len(line) > maxEmbedDescription {
+				break
+			}
+
+			builder.WriteString(line)
+		}
+
+		embed.setDescription(builder.String())
+
+		return embed.build(), nil
+	}
+}
+
+func onFriends(api *tfapi.TFAPI, database *store.Store) bot.Handler {
+	return func(ctx context.Context, _ *discordgo.Session, interaction *discordgo.InteractionCreate) (*discordgo.MessageEmbed, error) {
+		opts := bot.OptionMap(interaction.ApplicationCommandData().Options)
+
+		playerID, errPlayerID := resolvePlayer(ctx, database, interaction, opts)
+		if errPlayerID != nil {
+			return nil, errPlayerID
+		}
+
+		profile, errProfile := api.Profile(ctx, playerID)
+		if errProfile != nil {
+			return nil, errors.Join(errProfile, bot.ErrCommandExec)
+		}
+
+		var friendIDs []steamid.SteamID
+
+		for _, friend := range profile.Friends {
+			if friend.RemovedOn.IsZero() {
+				friendIDs = append(friendIDs, friend.SteamID)
+			}
+		}
+
+		embed := newEmbed(ctx, "[Friends] "+profile.PersonaName).setProfile(profile)
+
+		if len(friendIDs) == 0 {
+			embed.setDescription("No known friends")
+
+			return embed.build(), nil
+		}
+
+		states, errStates := api.SteamBans(ctx, friendIDs...)
+		if errStates != nil {
+			return nil, errors.Join(errStates, bot.ErrCommandExec)
+		}
+
+		var banned []tfapi.SteamBanState
+
+		for _, state := range states {
+			if state.Banned() {
+				banned = append(banned, state)
+			}
+		}
+
+		description := fmt.Sprintf("%d of %d friends have steam bans", len(banned), len(friendIDs))
+		if len(banned) > maxEmbedFields {
+			description += fmt.Sprintf("\nShowing %d of %d banned friends", maxEmbedFields, len(banned))
+		}
+
+		embed.setDescription(description)
+		embed.setStatus(statusClean)
+
+		if len(banned) > 0 {
+			embed.setStatus(statusSuspicious)
+		}
+
+		for _, state := range banned {
+			embed.addFieldInline(state.SteamID.String(), fmt.Sprintf("VAC: %d\nGame: %d\nCommunity: %t\nDays since: %d",
+				state.VACBans, state.GameBans, state.CommunityBanned, state.DaysSinceLastBan))
+		}
+
+		return embed.build(), nil
+	}
+}
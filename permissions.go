@@ -0,0 +1,96 @@
+package main
+
+import (
+	"slices"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leighmacdonald/tf-api-discord/store"
+)
+
+// permission is the tier a command requires, and that a member has been granted, within a guild.
+type permission int
+
+const (
+	permissionPublic permission = iota
+	permissionModerator
+	permissionAdmin
+)
+
+func (p permission) String() string {
+	switch p {
+	case permissionModerator:
+		return "Moderator"
+	case permissionAdmin:
+		return "Admin"
+	default:
+		return "Public"
+	}
+}
+
+// defaultMemberPermissions returns the discord permissions used to hide the command from members by default.
+// Guild admins can override these from the discord integration settings, so the tier is always checked
+// again when the command is invoked.
+func (p permission) defaultMemberPermissions() *int64 {
+	var perms int64
+
+	switch p {
+	case permissionModerator:
+		perms = discordgo.PermissionBanMembers
+	case permissionAdmin:
+		perms = discordgo.PermissionAdministrator
+	default:
+		perms = discordgo.PermissionViewChannel
+	}
+
+	return &perms
+}
+
+// memberPermission returns the highest permission tier the member has been granted within the guild. Members
+// are granted a tier by either having the role configured for it, or the equivalent discord permissions.
+func memberPermission(settings store.GuildSettings, member *discordgo.Member) permission {
+	if member == nil {
+		return permissionPublic
+	}
+
+	if member.Permissions&(discordgo.PermissionAdministrator|discordgo.PermissionManageGuild) != 0 ||
+		hasRole(member, settings.AdminRoleID) {
+		return permissionAdmin
+	}
+
+	if member.Permissions&discordgo.PermissionBanMembers != 0 || hasRole(member, settings.ModRoleID) {
+		return permissionModerator
+	}
+
+	return permissionPublic
+}
+
+func hasRole(member *discordgo.Member, roleID string) bool {
+	return roleID != "" && slices.Contains(member.Roles, roleID)
+}
+
+// contexts returns where the command can be invoked. Public commands are usable anywhere, including
+// DMs and group DMs when the app is installed to a user, while privileged commands only make sense
+// within a guild where the tier can be resolved.
+func (p permission) contexts() *[]discordgo.InteractionContextType {
+	if p == permissionPublic {
+		return &[]discordgo.InteractionContextType{
+			discordgo.InteractionContextGuild,
+			discordgo.InteractionContextBotDM,
+			discordgo.InteractionContextPrivateChannel,
+		}
+	}
+
+	return &[]discordgo.InteractionContextType{discordgo.InteractionContextGuild}
+}
+
+// integrationTypes returns the installation types the command is available to.
+func (p permission) integrationTypes() *[]discordgo.ApplicationIntegrationType {
+	if p == permissionPublic {
+		return &[]discordgo.ApplicationIntegrationType{
+			discordgo.ApplicationIntegrationGuildInstall,
+			discordgo.ApplicationIntegrationUserInstall,
+		}
+	}
+
+	return &[]discordgo.ApplicationIntegrationType{discordgo.ApplicationIntegrationGuildInstall}
+}
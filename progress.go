@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// progressInterval is the minimum time between progress updates. Commands that finish within it never
+// show any progress, and slower ones stay well within the rate limits of editing the response.
+const progressInterval = time.Second * 2
+
+type progressKey struct{}
+
+// progress shows how far along a slow command is by editing its deferred response, until the command
+// finishes and the final response replaces it.
+type progress struct {
+	session     *discordgo.Session
+	interaction *discordgo.Interaction
+
+	mu        sync.Mutex
+	updatedOn time.Time
+	finished  bool
+}
+
+// withProgress returns a copy of ctx that reportProgress uses to update the deferred response of the
+// interaction.
+func withProgress(ctx context.Context, session *discordgo.Session, interaction *discordgo.Interaction) (context.Context, *progress) {
+	current := &progress{session: session, interaction: interaction, updatedOn: time.Now()}
+
+	return context.WithValue(ctx, progressKey{}, current), current
+}
+
+// reportProgress shows the formatted status, such as "Checked 40/120 players", as the response to the
+// current command. Updates within progressInterval of the last are dropped, and it does nothing outside of
+// a command.
+func reportProgress(ctx context.Context, format string, args ...any) {
+	current, ok := ctx.Value(progressKey{}).(*progress)
+	if !ok {
+		return
+	}
+
+	current.update(ctx, fmt.Sprintf(format, args...))
+}
+
+func (p *progress) update(ctx context.Context, status string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.finished || ctx.Err() != nil || time.Since(p.updatedOn) < progressInterval {
+		return
+	}
+
+	p.updatedOn = time.Now()
+
+	embeds := []*discordgo.MessageEmbed{
+		newEmbed(ctx, "In progress").setSource("", time.Time{}).setDescription(status).build(),
+	}
+
+	if _, errEdit := p.session.InteractionResponseEdit(p.interaction, &discordgo.WebhookEdit{Embeds: &embeds},
+		discordgo.WithContext(ctx)); errEdit != nil && ctx.Err() == nil {
+		slog.Warn("Failed to update command progress", slog.String("error", errEdit.Error()))
+	}
+}
+
+// finish stops any further updates, waiting for one in flight so it can't overwrite the final response.
+func (p *progress) finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.finished = true
+}
@@ -0,0 +1,113 @@
+package render
+
+import (
+	"image"
+	"image/color"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+const (
+	cardWidth       = 600
+	cardPadding     = 20
+	cardAvatarSize  = 96
+	cardStatHeight  = 52
+	cardStatsPerRow = 4
+)
+
+// BadgeLevel sets the color of a badge.
+type BadgeLevel int
+
+const (
+	BadgeInfo BadgeLevel = iota
+	BadgeWarning
+	BadgeDanger
+)
+
+func (l BadgeLevel) color() color.RGBA {
+	switch l {
+	case BadgeDanger:
+		return color.RGBA{R: 0xed, G: 0x42, B: 0x45, A: 0xff}
+	case BadgeWarning:
+		return color.RGBA{R: 0xc2, G: 0x8a, B: 0x00, A: 0xff}
+	default:
+		return colorAccent
+	}
+}
+
+// Badge is a short label shown under the players name, such as a ban type.
+type Badge struct {
+	Label string
+	Level BadgeLevel
+}
+
+// Stat is a single labelled value shown in the grid at the bottom of the card.
+type Stat struct {
+	Label string
+	Value string
+}
+
+// Card is the data shown on a player card.
+type Card struct {
+	Name    string
+	SteamID string
+	// Avatar is optional, a placeholder is drawn when it is nil.
+	Avatar image.Image
+	Badges []Badge
+	Stats  []Stat
+}
+
+// PlayerCard renders the card as a PNG image.
+func PlayerCard(card Card) ([]byte, error) {
+	drawMu.Lock()
+	defer drawMu.Unlock()
+
+	rows := (len(card.Stats) + cardStatsPerRow - 1) / cardStatsPerRow
+	height := cardPadding*3 + cardAvatarSize + rows*cardStatHeight
+
+	c, errCanvas := newCanvas(cardWidth, height)
+	if errCanvas != nil {
+		return nil, errCanvas
+	}
+
+	avatarRect := image.Rect(cardPadding, cardPadding, cardPadding+cardAvatarSize, cardPadding+cardAvatarSize)
+	if card.Avatar != nil {
+		xdraw.CatmullRom.Scale(c.img, avatarRect, card.Avatar, card.Avatar.Bounds(), xdraw.Src, nil)
+	} else {
+		c.fill(avatarRect, colorPanel)
+	}
+
+	left := avatarRect.Max.X + cardPadding
+	textWidth := cardWidth - left - cardPadding
+
+	c.text(c.fonts.title, left, cardPadding+22, colorText, c.fitText(c.fonts.title, card.Name, textWidth))
+	c.text(c.fonts.small, left, cardPadding+42, colorMuted, card.SteamID)
+
+	badgeX := left
+	badgeY := cardPadding + 56
+
+	for _, badge := range card.Badges {
+		width := c.textWidth(c.fonts.small, badge.Label) + 12
+		if badgeX+width > cardWidth-cardPadding {
+			break
+		}
+
+		c.fill(image.Rect(badgeX, badgeY, badgeX+width, badgeY+20), badge.Level.color())
+		c.text(c.fonts.small, badgeX+6, badgeY+15, colorText, badge.Label)
+		badgeX += width + 6
+	}
+
+	statWidth := (cardWidth - cardPadding*2) / cardStatsPerRow
+	top := avatarRect.Max.Y + cardPadding
+
+	for i, stat := range card.Stats {
+		x := cardPadding + (i%cardStatsPerRow)*statWidth
+		y := top + (i/cardStatsPerRow)*cardStatHeight
+
+		c.fill(image.Rect(x, y, x+statWidth-6, y+cardStatHeight-6), colorPanel)
+		c.text(c.fonts.small, x+8, y+16, colorMuted, c.fitText(c.fonts.small, stat.Label, statWidth-20))
+		c.text(c.fonts.regular, x+8, y+37, colorText, c.fitText(c.fonts.regular, stat.Value, statWidth-20))
+	}
+
+	return c.encode()
+}
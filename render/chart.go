@@ -0,0 +1,318 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+)
+
+const (
+	chartWidth       = 700
+	chartHeight      = 360
+	chartMarginLeft  = 60
+	chartMarginRight = 20
+	chartMarginTop   = 56
+	chartMarginBot   = 44
+	chartGridLines   = 5
+)
+
+// Point is a single labelled value on a line chart.
+type Point struct {
+	Label string
+	Value float64
+}
+
+// Series is a named set of values drawn in a single color, one value per label on a bar chart.
+type Series struct {
+	Name   string
+	Color  color.RGBA
+	Values []float64
+}
+
+// Slice is a single segment of a pie chart.
+type Slice struct {
+	Label string
+	Value float64
+}
+
+// plot is the area of a chart that values are drawn within, along with its vertical scale.
+type plot struct {
+	rect image.Rectangle
+	max  float64
+}
+
+func (p plot) y(value float64) int {
+	return p.rect.Max.Y - int(math.Round(value/p.max*float64(p.rect.Dy())))
+}
+
+// newPlot draws the title, grid and y axis labels for a chart with values up to maxValue.
+func newPlot(c *canvas, title string, maxValue float64) plot {
+	c.text(c.fonts.title, chartMarginLeft, 34, colorText, c.fitText(c.fonts.title, title, chartWidth-chartMarginLeft*2))
+
+	p := plot{
+		rect: image.Rect(chartMarginLeft, chartMarginTop, chartWidth-chartMarginRight, chartHeight-chartMarginBot),
+		max:  niceMax(maxValue),
+	}
+
+	for i := 0; i <= chartGridLines; i++ {
+		value := p.max / chartGridLines * float64(i)
+		y := p.y(value)
+		label := formatValue(value)
+
+		c.fill(image.Rect(p.rect.Min.X, y, p.rect.Max.X, y+1), colorGrid)
+		c.text(c.fonts.small, p.rect.Min.X-8-c.textWidth(c.fonts.small, label), y+4, colorMuted, label)
+	}
+
+	return p
+}
+
+// xLabels draws the labels under the plot, skipping labels when there are too many to fit.
+func (p plot) xLabels(c *canvas, labels []string, center func(i int) int) {
+	step := 1
+	for step < len(labels) && len(labels)/step*48 > p.rect.Dx() {
+		step++
+	}
+
+	width := max(p.rect.Dx()/len(labels)*step-4, 40)
+
+	for i := 0; i < len(labels); i += step {
+		label := c.fitText(c.fonts.small, labels[i], width)
+		labelWidth := c.textWidth(c.fonts.small, label)
+		x := min(max(center(i)-labelWidth/2, 0), chartWidth-labelWidth)
+
+		c.text(c.fonts.small, x, p.rect.Max.Y+18, colorMuted, label)
+	}
+}
+
+// legend draws the name of each series with its color in the top right corner.
+func legend(c *canvas, names []string, colors []color.RGBA) {
+	x := chartWidth - chartMarginRight
+
+	for i := len(names) - 1; i >= 0; i-- {
+		x -= c.textWidth(c.fonts.small, names[i])
+		c.text(c.fonts.small, x, 30, colorText, names[i])
+		x -= 18
+		c.fill(image.Rect(x, 20, x+12, 32), colors[i])
+		x -= 14
+	}
+}
+
+// LineChart renders the points in order as a line, such as a players DPM over their recent matches.
+func LineChart(title string, points []Point) ([]byte, error) {
+	if len(points) == 0 {
+		return nil, ErrNoData
+	}
+
+	drawMu.Lock()
+	defer drawMu.Unlock()
+
+	c, errCanvas := newCanvas(chartWidth, chartHeight)
+	if errCanvas != nil {
+		return nil, errCanvas
+	}
+
+	var (
+		maxValue float64
+		labels   = make([]string, len(points))
+	)
+
+	for i, point := range points {
+		maxValue = max(maxValue, point.Value)
+		labels[i] = point.Label
+	}
+
+	p := newPlot(c, title, maxValue)
+
+	x := func(i int) int {
+		if len(points) == 1 {
+			return p.rect.Min.X + p.rect.Dx()/2
+		}
+
+		return p.rect.Min.X + 8 + i*(p.rect.Dx()-16)/(len(points)-1)
+	}
+
+	for i := 1; i < len(points); i++ {
+		c.line(x(i-1), p.y(points[i-1].Value), x(i), p.y(points[i].Value), 3, colorAccent)
+	}
+
+	for i, point := range points {
+		px, py := x(i), p.y(point.Value)
+		c.fill(image.Rect(px-4, py-4, px+5, py+5), colorText)
+	}
+
+	p.xLabels(c, labels, x)
+
+	return c.encode()
+}
+
+// BarChart renders grouped bars, one group per label with a bar for each series. Used for comparing the
+// teams, such as the damage each team did per round.
+func BarChart(title string, labels []string, series ...Series) ([]byte, error) {
+	if len(labels) == 0 || len(series) == 0 {
+		return nil, ErrNoData
+	}
+
+	drawMu.Lock()
+	defer drawMu.Unlock()
+
+	c, errCanvas := newCanvas(chartWidth, chartHeight)
+	if errCanvas != nil {
+		return nil, errCanvas
+	}
+
+	var (
+		maxValue float64
+		names    = make([]string, len(series))
+		colors   = make([]color.RGBA, len(series))
+	)
+
+	for i, values := range series {
+		names[i] = values.Name
+		colors[i] = values.Color
+
+		for _, value := range values.Values {
+			maxValue = max(maxValue, value)
+		}
+	}
+
+	p := newPlot(c, title, maxValue)
+	legend(c, names, colors)
+
+	groupWidth := p.rect.Dx() / len(labels)
+	barWidth := max((groupWidth-8)/len(series), 1)
+
+	center := func(i int) int {
+		return p.rect.Min.X + i*groupWidth + groupWidth/2
+	}
+
+	for i := range labels {
+		left := center(i) - barWidth*len(series)/2
+
+		for j, values := range series {
+			if i >= len(values.Values) {
+				continue
+			}
+
+			x := left + j*barWidth
+			c.fill(image.Rect(x, p.y(values.Values[i]), x+barWidth-1, p.rect.Max.Y), values.Color)
+		}
+	}
+
+	p.xLabels(c, labels, center)
+
+	return c.encode()
+}
+
+// PieChart renders the share of each slice of the total, such as the playtime of each class. Slices are
+// drawn clockwise from the top in the order given.
+func PieChart(title string, slices []Slice) ([]byte, error) {
+	var total float64
+
+	for _, slice := range slices {
+		total += max(slice.Value, 0)
+	}
+
+	if total == 0 {
+		return nil, ErrNoData
+	}
+
+	drawMu.Lock()
+	defer drawMu.Unlock()
+
+	c, errCanvas := newCanvas(chartWidth, chartHeight)
+	if errCanvas != nil {
+		return nil, errCanvas
+	}
+
+	c.text(c.fonts.title, chartMarginLeft, 34, colorText, c.fitText(c.fonts.title, title, chartWidth-chartMarginLeft*2))
+
+	const radius = 130
+
+	centerX, centerY := chartMarginLeft+radius, chartMarginTop+(chartHeight-chartMarginTop)/2-8
+
+	// The end of each slice as a fraction of a full turn.
+	ends := make([]float64, len(slices))
+
+	var cumulative float64
+
+	for i, slice := range slices {
+		cumulative += max(slice.Value, 0) / total
+		ends[i] = cumulative
+	}
+
+	for y := -radius; y <= radius; y++ {
+		for x := -radius; x <= radius; x++ {
+			if x*x+y*y > radius*radius {
+				continue
+			}
+
+			// Angle clockwise from the top, as a fraction of a full turn.
+			turn := math.Atan2(float64(x), float64(-y)) / (2 * math.Pi)
+			if turn < 0 {
+				turn++
+			}
+
+			index := len(ends) - 1
+			for i, end := range ends {
+				if turn < end {
+					index = i
+
+					break
+				}
+			}
+
+			c.img.SetRGBA(centerX+x, centerY+y, palette[index%len(palette)])
+		}
+	}
+
+	legendX := centerX + radius + 40
+	legendY := chartMarginTop + 10
+
+	for i, slice := range slices {
+		if slice.Value <= 0 {
+			continue
+		}
+
+		if legendY > chartHeight-20 {
+			break
+		}
+
+		label := fmt.Sprintf("%s  %.1f%%", slice.Label, slice.Value/total*100)
+
+		c.fill(image.Rect(legendX, legendY-11, legendX+12, legendY+1), palette[i%len(palette)])
+		c.text(c.fonts.regular, legendX+20, legendY, colorText,
+			c.fitText(c.fonts.regular, label, chartWidth-legendX-20-chartMarginRight))
+
+		legendY += 24
+	}
+
+	return c.encode()
+}
+
+// niceMax rounds the maximum value up to 1, 2 or 5 times a power of ten so the grid lines fall on
+// round numbers.
+func niceMax(value float64) float64 {
+	if value <= 0 {
+		return chartGridLines
+	}
+
+	magnitude := math.Pow(10, math.Floor(math.Log10(value/chartGridLines)))
+
+	for _, multiple := range []float64{1, 2, 5, 10} {
+		if step := multiple * magnitude; step*chartGridLines >= value {
+			return step * chartGridLines
+		}
+	}
+
+	return 10 * magnitude * chartGridLines
+}
+
+func formatValue(value float64) string {
+	if value >= 10000 {
+		return strconv.FormatFloat(value/1000, 'f', -1, 64) + "k"
+	}
+
+	return strconv.FormatFloat(math.Round(value*100)/100, 'f', -1, 64)
+}
@@ -0,0 +1,203 @@
+// Package render draws player cards and charts as PNG images. Everything is drawn in process using the
+// embedded Go fonts, so no external services or system fonts are required.
+//
+// Rendering is deterministic, the same input always produces the same bytes. Nothing depends on the
+// current time, map iteration order or the host, which allows the output to be compared against golden
+// images.
+package render
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+var (
+	ErrNoData = errors.New("nothing to render")
+	ErrFont   = errors.New("failed to load font")
+)
+
+// The colors shared by all images, chosen to match the discord dark theme.
+var (
+	colorBackground = color.RGBA{R: 0x2b, G: 0x2d, B: 0x31, A: 0xff}
+	colorPanel      = color.RGBA{R: 0x1e, G: 0x1f, B: 0x22, A: 0xff}
+	colorText       = color.RGBA{R: 0xf2, G: 0xf3, B: 0xf5, A: 0xff}
+	colorMuted      = color.RGBA{R: 0x94, G: 0x9b, B: 0xa4, A: 0xff}
+	colorGrid       = color.RGBA{R: 0x3f, G: 0x41, B: 0x47, A: 0xff}
+	colorAccent     = color.RGBA{R: 0x58, G: 0x65, B: 0xf2, A: 0xff}
+
+	// ColorRed and ColorBlu are the team colors used by tf2.
+	ColorRed = color.RGBA{R: 0xb8, G: 0x38, B: 0x3b, A: 0xff}
+	ColorBlu = color.RGBA{R: 0x58, G: 0x85, B: 0xa2, A: 0xff}
+
+	// palette is used for chart series without a fixed color, such as pie slices.
+	palette = []color.RGBA{
+		{R: 0x58, G: 0x65, B: 0xf2, A: 0xff},
+		{R: 0x57, G: 0xf2, B: 0x87, A: 0xff},
+		{R: 0xfe, G: 0xe7, B: 0x5c, A: 0xff},
+		{R: 0xed, G: 0x42, B: 0x45, A: 0xff},
+		{R: 0xeb, G: 0x45, B: 0x9e, A: 0xff},
+		{R: 0x56, G: 0xb4, B: 0xe9, A: 0xff},
+		{R: 0xe6, G: 0x9f, B: 0x00, A: 0xff},
+		{R: 0x00, G: 0x9e, B: 0x73, A: 0xff},
+		{R: 0x99, G: 0xaa, B: 0xb5, A: 0xff},
+	}
+)
+
+type fonts struct {
+	title   font.Face
+	regular font.Face
+	small   font.Face
+}
+
+// loadFonts parses the embedded fonts once, the faces are safe to share since drawing is serialized
+// by drawMu.
+var loadFonts = sync.OnceValues(func() (fonts, error) {
+	regular, errRegular := opentype.Parse(goregular.TTF)
+	if errRegular != nil {
+		return fonts{}, errors.Join(errRegular, ErrFont)
+	}
+
+	bold, errBold := opentype.Parse(gobold.TTF)
+	if errBold != nil {
+		return fonts{}, errors.Join(errBold, ErrFont)
+	}
+
+	var (
+		loaded fonts
+		errs   []error
+	)
+
+	newFace := func(parsed *opentype.Font, size float64) font.Face {
+		face, errFace := opentype.NewFace(parsed, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+		errs = append(errs, errFace)
+
+		return face
+	}
+
+	loaded.title = newFace(bold, 22)
+	loaded.regular = newFace(regular, 15)
+	loaded.small = newFace(regular, 12)
+
+	if err := errors.Join(errs...); err != nil {
+		return fonts{}, errors.Join(err, ErrFont)
+	}
+
+	return loaded, nil
+})
+
+// drawMu serializes drawing, font faces cache glyphs internally and are not safe for concurrent use.
+var drawMu sync.Mutex
+
+// canvas is an image being drawn.
+type canvas struct {
+	img   *image.RGBA
+	fonts fonts
+}
+
+func newCanvas(width int, height int) (*canvas, error) {
+	loaded, errFonts := loadFonts()
+	if errFonts != nil {
+		return nil, errFonts
+	}
+
+	c := &canvas{img: image.NewRGBA(image.Rect(0, 0, width, height)), fonts: loaded}
+	c.fill(c.img.Bounds(), colorBackground)
+
+	return c, nil
+}
+
+func (c *canvas) fill(rect image.Rectangle, fill color.Color) {
+	draw.Draw(c.img, rect, image.NewUniform(fill), image.Point{}, draw.Src)
+}
+
+// text draws s with its baseline starting at x, y.
+func (c *canvas) text(face font.Face, x int, y int, fill color.Color, s string) {
+	drawer := font.Drawer{Dst: c.img, Src: image.NewUniform(fill), Face: face, Dot: fixed.P(x, y)}
+	drawer.DrawString(s)
+}
+
+func (c *canvas) textWidth(face font.Face, s string) int {
+	return font.MeasureString(face, s).Ceil()
+}
+
+// fitText shortens s until it fits within width pixels.
+func (c *canvas) fitText(face font.Face, s string, width int) string {
+	if c.textWidth(face, s) <= width {
+		return s
+	}
+
+	runes := []rune(s)
+	for len(runes) > 0 && c.textWidth(face, string(runes)+"…") > width {
+		runes = runes[:len(runes)-1]
+	}
+
+	return string(runes) + "…"
+}
+
+// line draws a line of the given thickness between two points.
+func (c *canvas) line(x0 int, y0 int, x1 int, y1 int, thickness int, stroke color.Color) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := sign(x1-x0), sign(y1-y0)
+	errAcc := dx + dy
+	half := thickness / 2
+
+	for {
+		c.fill(image.Rect(x0-half, y0-half, x0-half+thickness, y0-half+thickness), stroke)
+
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+
+		e2 := 2 * errAcc
+		if e2 >= dy {
+			errAcc += dy
+			x0 += sx
+		}
+
+		if e2 <= dx {
+			errAcc += dx
+			y0 += sy
+		}
+	}
+}
+
+func (c *canvas) encode() ([]byte, error) {
+	var buf bytes.Buffer
+
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := encoder.Encode(&buf, c.img); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+
+	return value
+}
+
+func sign(value int) int {
+	switch {
+	case value < 0:
+		return -1
+	case value > 0:
+		return 1
+	default:
+		return 0
+	}
+}
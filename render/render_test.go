@@ -0,0 +1,269 @@
+package render
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden images in testdata")
+
+// goldenTolerance is the largest difference allowed in each 8 bit color channel, so that small changes in
+// rounding don't fail the tests.
+const goldenTolerance = 2
+
+// testAvatar is a gradient, so scaling it exercises the interpolation.
+func testAvatar() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for y := range 64 {
+		for x := range 64 {
+			img.SetRGBA(x, y, color.RGBA{R: uint8(x * 4), G: uint8(y * 4), B: 0x80, A: 0xff})
+		}
+	}
+
+	return img
+}
+
+// assertGolden compares the image with testdata/<name>.png, or replaces it when run with -update.
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".png")
+
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		return
+	}
+
+	want, errRead := os.ReadFile(path)
+	if errRead != nil {
+		t.Fatalf("failed to read golden image, run the tests with -update to create it: %v", errRead)
+	}
+
+	// The encoded bytes depend on the png encoder of the go release, so only the pixels are compared.
+	if errCompare := comparePixels(got, want); errCompare != nil {
+		actual := filepath.Join(t.TempDir(), name+".png")
+		if err := os.WriteFile(actual, got, 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		t.Errorf("image differs from %s, %v, the rendered image was written to %s", path, errCompare, actual)
+	}
+}
+
+// comparePixels decodes both images and checks that their pixels are within goldenTolerance of each other.
+func comparePixels(got []byte, want []byte) error {
+	gotImage, errGot := png.Decode(bytes.NewReader(got))
+	if errGot != nil {
+		return fmt.Errorf("failed to decode the rendered image: %w", errGot)
+	}
+
+	wantImage, errWant := png.Decode(bytes.NewReader(want))
+	if errWant != nil {
+		return fmt.Errorf("failed to decode the golden image: %w", errWant)
+	}
+
+	if gotImage.Bounds() != wantImage.Bounds() {
+		return fmt.Errorf("expected bounds %v, got %v", wantImage.Bounds(), gotImage.Bounds())
+	}
+
+	bounds := gotImage.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			gotColor := color.NRGBAModel.Convert(gotImage.At(x, y)).(color.NRGBA)
+			wantColor := color.NRGBAModel.Convert(wantImage.At(x, y)).(color.NRGBA)
+
+			for _, channel := range [][2]uint8{
+				{gotColor.R, wantColor.R}, {gotColor.G, wantColor.G}, {gotColor.B, wantColor.B}, {gotColor.A, wantColor.A},
+			} {
+				if max(channel[0], channel[1])-min(channel[0], channel[1]) > goldenTolerance {
+					return fmt.Errorf("pixel %d,%d is %v, expected %v", x, y, gotColor, wantColor)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func TestPlayerCard(t *testing.T) {
+	tests := []struct {
+		name string
+		card Card
+	}{
+		{
+			name: "card",
+			card: Card{
+				Name:    "Player",
+				SteamID: "76561197960287930",
+				Avatar:  testAvatar(),
+				Badges: []Badge{
+					{Label: "VAC Banned", Level: BadgeDanger},
+					{Label: "Private", Level: BadgeWarning},
+					{Label: "ETF2L", Level: BadgeInfo},
+				},
+				Stats: []Stat{
+					{Label: "Logs", Value: "1234"},
+					{Label: "DPM", Value: "245.3"},
+					{Label: "KD", Value: "2.1"},
+					{Label: "Hours", Value: "5000"},
+					{Label: "Sourcebans", Value: "2"},
+				},
+			},
+		},
+		{
+			name: "card_no_avatar",
+			card: Card{
+				Name:    "A player with a name far too long to fit on the card without being cut",
+				SteamID: "76561197960287930",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, errRender := PlayerCard(test.card)
+			if errRender != nil {
+				t.Fatal(errRender)
+			}
+
+			assertGolden(t, test.name, got)
+		})
+	}
+}
+
+func TestLineChart(t *testing.T) {
+	tests := []struct {
+		name   string
+		points []Point
+	}{
+		{
+			name: "line",
+			points: []Point{
+				{Label: "1", Value: 210}, {Label: "2", Value: 260}, {Label: "3", Value: 180},
+				{Label: "4", Value: 320}, {Label: "5", Value: 295},
+			},
+		},
+		{
+			name:   "line_single",
+			points: []Point{{Label: "1", Value: 210}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, errRender := LineChart("DPM", test.points)
+			if errRender != nil {
+				t.Fatal(errRender)
+			}
+
+			assertGolden(t, test.name, got)
+		})
+	}
+
+	if _, errRender := LineChart("DPM", nil); !errors.Is(errRender, ErrNoData) {
+		t.Errorf("expected ErrNoData for an empty series, got %v", errRender)
+	}
+}
+
+func TestBarChart(t *testing.T) {
+	got, errRender := BarChart("Damage per round", []string{"1", "2", "3"},
+		Series{Name: "RED", Color: ColorRed, Values: []float64{1200, 900, 1500}},
+		Series{Name: "BLU", Color: ColorBlu, Values: []float64{1000, 1300}})
+	if errRender != nil {
+		t.Fatal(errRender)
+	}
+
+	assertGolden(t, "bar", got)
+
+	if _, errRender := BarChart("Empty", nil, Series{Name: "RED"}); !errors.Is(errRender, ErrNoData) {
+		t.Errorf("expected ErrNoData without labels, got %v", errRender)
+	}
+
+	if _, errRender := BarChart("Empty", []string{"1"}); !errors.Is(errRender, ErrNoData) {
+		t.Errorf("expected ErrNoData without series, got %v", errRender)
+	}
+}
+
+func TestPieChart(t *testing.T) {
+	tests := []struct {
+		name   string
+		slices []Slice
+	}{
+		{
+			name: "pie",
+			slices: []Slice{
+				{Label: "Scout", Value: 40}, {Label: "Soldier", Value: 35}, {Label: "Medic", Value: 25},
+			},
+		},
+		{
+			name: "pie_zero_negative",
+			slices: []Slice{
+				{Label: "Scout", Value: 40}, {Label: "Pyro", Value: 0}, {Label: "Spy", Value: -10},
+				{Label: "Medic", Value: 60},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, errRender := PieChart("Class playtime", test.slices)
+			if errRender != nil {
+				t.Fatal(errRender)
+			}
+
+			assertGolden(t, test.name, got)
+		})
+	}
+
+	for _, slices := range [][]Slice{nil, {{Label: "Pyro", Value: 0}, {Label: "Spy", Value: -10}}} {
+		if _, errRender := PieChart("Class playtime", slices); !errors.Is(errRender, ErrNoData) {
+			t.Errorf("expected ErrNoData for %v, got %v", slices, errRender)
+		}
+	}
+}
+
+func TestComparePixels(t *testing.T) {
+	encode := func(img image.Image, level png.CompressionLevel) []byte {
+		var buf bytes.Buffer
+		if err := (&png.Encoder{CompressionLevel: level}).Encode(&buf, img); err != nil {
+			t.Fatal(err)
+		}
+
+		return buf.Bytes()
+	}
+
+	avatar := testAvatar()
+
+	// Different encodings of the same pixels must match.
+	if err := comparePixels(encode(avatar, png.BestSpeed), encode(avatar, png.BestCompression)); err != nil {
+		t.Errorf("expected the same pixels to match: %v", err)
+	}
+
+	changed := image.NewRGBA(avatar.Bounds())
+	copy(changed.Pix, avatar.(*image.RGBA).Pix)
+
+	changed.SetRGBA(1, 1, color.RGBA{R: 4 + goldenTolerance, G: 4, B: 0x80, A: 0xff})
+	if err := comparePixels(encode(changed, png.DefaultCompression), encode(avatar, png.DefaultCompression)); err != nil {
+		t.Errorf("expected a difference within the tolerance to match: %v", err)
+	}
+
+	changed.SetRGBA(1, 1, color.RGBA{R: 0xff, G: 4, B: 0x80, A: 0xff})
+	if err := comparePixels(encode(changed, png.DefaultCompression), encode(avatar, png.DefaultCompression)); err == nil {
+		t.Error("expected a changed pixel to differ")
+	}
+}
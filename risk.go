@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/leighmacdonald/steamid/v4/steamid"
+	"github.com/leighmacdonald/tf-api-discord/risk"
+	"github.com/leighmacdonald/tf-api-discord/store"
+	"github.com/leighmacdonald/tf-api-discord/tfapi"
+)
+
+// assessPlayer gathers the supplementary data used by the risk engine and computes the players risk score
+// using the guilds weights. Failing to fetch the supplementary data only reduces the accuracy of the score.
+func assessPlayer(ctx context.Context, api *tfapi.TFAPI, settings store.GuildSettings, profile tfapi.Profile) risk.Assessment {
+	input := risk.Input{Profile: profile}
+
+	if settings.MinAccountAge > 0 {
+		input.NewAccountAge = time.Duration(settings.MinAccountAge) * time.Hour * 24
+	}
+
+	matches, errMatches := api.BotDetector(ctx, profile.SteamID)
+	if errMatches != nil {
+		slog.Error("Failed to fetch bot detector matches", slog.String("error", errMatches.Error()))
+	}
+
+	input.BotDetector = matches
+
+	reputations, errReputations := api.SteamRep(ctx, profile.SteamID)
+	if errReputations != nil {
+		slog.Error("Failed to fetch steamrep reputation", slog.String("error", errReputations.Error()))
+	}
+
+	input.Reputations = reputations
+
+	var friendIDs []steamid.SteamID
+
+	for _, friend := range profile.Friends {
+		if friend.RemovedOn.IsZero() {
+			friendIDs = append(friendIDs, friend.SteamID)
+		}
+	}
+
+	if len(friendIDs) > 0 {
+		friendBans, errFriendBans := api.SteamBans(ctx, friendIDs...)
+		if errFriendBans != nil {
+			slog.Error("Failed to fetch friend bans", slog.String("error", errFriendBans.Error()))
+		}
+
+		input.FriendBans = friendBans
+	}
+
+	return risk.Assess(input, risk.DefaultWeights().WithOverrides(settings.RiskWeights))
+}
+
+// riskBreakdown formats the factors that contributed to the score, one per line.
+func riskBreakdown(assessment risk.Assessment) string {
+	if len(assessment.Breakdown) == 0 {
+		return "No risk factors found"
+	}
+
+	lines := make([]string, len(assessment.Breakdown))
+	for i, contribution := range assessment.Breakdown {
+		lines[i] = fmt.Sprintf("`+%d` %s", contribution.Points, contribution.Detail)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// riskStatus flags players with a medium or high risk score as suspicious.
+func riskStatus(assessment risk.Assessment) status {
+	switch assessment.Level() {
+	case "Medium", "High":
+		return statusSuspicious
+	default:
+		return statusClean
+	}
+}
+
+func addRiskFields(embed *embedBuilder, assessment risk.Assessment) {
+	embed.raiseStatus(riskStatus(assessment))
+	embed.addFieldInline("Risk Score", fmt.Sprintf("%d/%d (%s)", assessment.Score, risk.MaxScore, assessment.Level()))
+	embed.addField("Risk Factors", riskBreakdown(assessment))
+}
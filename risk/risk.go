@@ -0,0 +1,374 @@
+// Package risk computes a composite 0-100 risk score for a player from the data available through tfapi.
+//
+// Each factor has a weight, the maximum number of points it can contribute, and a severity between 0 and 1
+// derived from the players data. The score is the sum of weight * severity for all factors, capped at 100,
+// so a single strong signal such as a recent VAC ban can stand on its own while weak signals only add up.
+package risk
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/leighmacdonald/tf-api-discord/tfapi"
+)
+
+// MaxScore is the highest possible risk score.
+const MaxScore = 100
+
+// Factor is a single signal that contributes to the risk score.
+type Factor string
+
+const (
+	FactorVACBans        Factor = "vac_bans"
+	FactorGameBans       Factor = "game_bans"
+	FactorCommunityBan   Factor = "community_ban"
+	FactorEconomyBan     Factor = "economy_ban"
+	FactorSourceBans     Factor = "sourcebans"
+	FactorBotDetector    Factor = "bot_detector"
+	FactorSteamRep       Factor = "steamrep"
+	FactorFriendBans     Factor = "friend_bans"
+	FactorAccountAge     Factor = "account_age"
+	FactorPrivateProfile Factor = "private_profile"
+	FactorNoLogs         Factor = "no_logs"
+)
+
+// Factors returns all the known factors, in the order they are evaluated.
+func Factors() []Factor {
+	return []Factor{
+		FactorVACBans, FactorGameBans, FactorCommunityBan, FactorEconomyBan, FactorSourceBans, FactorBotDetector,
+		FactorSteamRep, FactorFriendBans, FactorAccountAge, FactorPrivateProfile, FactorNoLogs,
+	}
+}
+
+// Weights is the maximum number of points each factor can contribute.
+type Weights map[Factor]int
+
+// DefaultWeights returns the weights used when a guild has not overridden them.
+func DefaultWeights() Weights {
+	return Weights{
+		FactorVACBans:        40,
+		FactorGameBans:       20,
+		FactorCommunityBan:   5,
+		FactorEconomyBan:     5,
+		FactorSourceBans:     30,
+		FactorBotDetector:    40,
+		FactorSteamRep:       30,
+		FactorFriendBans:     15,
+		FactorAccountAge:     10,
+		FactorPrivateProfile: 10,
+		FactorNoLogs:         5,
+	}
+}
+
+// WithOverrides returns a copy of the weights with the overrides applied. Unknown factors are ignored.
+func (w Weights) WithOverrides(overrides map[string]int) Weights {
+	weights := Weights{}
+	for factor, weight := range w {
+		weights[factor] = weight
+	}
+
+	for name, weight := range overrides {
+		if slices.Contains(Factors(), Factor(name)) {
+			weights[Factor(name)] = weight
+		}
+	}
+
+	return weights
+}
+
+// Input is the player data used to assess a player. Only Profile is required, the other data improves
+// the accuracy of the assessment when available.
+type Input struct {
+	Profile     tfapi.Profile
+	BotDetector []tfapi.BotDetectorMatch
+	Reputations []tfapi.Reputation
+	// FriendBans is the steam ban state of the players current friends.
+	FriendBans []tfapi.SteamBanState
+	// NewAccountAge is the age under which accounts are considered new. Defaults to 30 days.
+	NewAccountAge time.Duration
+	// Now is the time the assessment is made at, defaults to the current time.
+	Now time.Time
+}
+
+// Contribution is the points a single factor added to the score.
+type Contribution struct {
+	Factor Factor
+	Points int
+	Detail string
+}
+
+// Assessment is the computed risk of a player.
+type Assessment struct {
+	Score     int
+	Breakdown []Contribution
+}
+
+// Level is a coarse description of the score.
+func (a Assessment) Level() string {
+	switch {
+	case a.Score >= 60:
+		return "High"
+	case a.Score >= 25:
+		return "Medium"
+	case a.Score > 0:
+		return "Low"
+	default:
+		return "None"
+	}
+}
+
+// Assess computes the risk score of a player.
+func Assess(input Input, weights Weights) Assessment {
+	if input.Now.IsZero() {
+		input.Now = time.Now()
+	}
+
+	if input.NewAccountAge <= 0 {
+		input.NewAccountAge = time.Hour * 24 * 30
+	}
+
+	var (
+		assessment Assessment
+		total      float64
+	)
+
+	for _, factor := range Factors() {
+		severity, detail := evaluate(factor, input)
+		if severity <= 0 || weights[factor] <= 0 {
+			continue
+		}
+
+		points := float64(weights[factor]) * min(severity, 1)
+		total += points
+
+		assessment.Breakdown = append(assessment.Breakdown, Contribution{
+			Factor: factor,
+			Points: int(math.Round(points)),
+			Detail: detail,
+		})
+	}
+
+	assessment.Score = min(int(math.Round(total)), MaxScore)
+
+	return assessment
+}
+
+func evaluate(factor Factor, input Input) (float64, string) {
+	profile := input.Profile
+
+	switch factor {
+	case FactorVACBans:
+		return vacSeverity(profile)
+	case FactorGameBans:
+		if profile.GameBans == 0 {
+			return 0, ""
+		}
+
+		return min(float64(profile.GameBans)/2, 1), fmt.Sprintf("%d game bans", profile.GameBans)
+	case FactorCommunityBan:
+		if !profile.CommunityBanned {
+			return 0, ""
+		}
+
+		return 1, "Community banned"
+	case FactorEconomyBan:
+		switch profile.EconomyBan {
+		case tfapi.EconomyBanBanned:
+			return 1, "Economy banned"
+		case tfapi.EconomyBanProbation:
+			return 0.5, "Economy probation"
+		default:
+			return 0, ""
+		}
+	case FactorSourceBans:
+		return sourceBanSeverity(profile.Bans)
+	case FactorBotDetector:
+		return botDetectorSeverity(input.BotDetector)
+	case FactorSteamRep:
+		return steamRepSeverity(input.Reputations)
+	case FactorFriendBans:
+		return friendSeverity(input.FriendBans)
+	case FactorAccountAge:
+		// Private profiles report the unix epoch rather than a creation date.
+		if profile.TimeCreated.Unix() <= 0 {
+			return 0, ""
+		}
+
+		age := input.Now.Sub(profile.TimeCreated)
+		switch {
+		case age < input.NewAccountAge:
+			return 1, fmt.Sprintf("Created %d days ago", int(age.Hours()/24))
+		case age < time.Hour*24*365:
+			return 0.5, "Created less than a year ago"
+		default:
+			return 0, ""
+		}
+	case FactorPrivateProfile:
+		if profile.Visibility == tfapi.VisibilityPublic {
+			return 0, ""
+		}
+
+		return 1, "Profile is " + profile.Visibility.String()
+	case FactorNoLogs:
+		if profile.LogsCount > 0 {
+			return 0, ""
+		}
+
+		return 1, "No logs.tf history"
+	default:
+		return 0, ""
+	}
+}
+
+// vacSeverity weights VAC bans by how recent the last ban was, old bans are much less relevant.
+func vacSeverity(profile tfapi.Profile) (float64, string) {
+	if profile.VACBans == 0 {
+		return 0, ""
+	}
+
+	detail := fmt.Sprintf("%d VAC bans, last %d days ago", profile.VACBans, profile.DaysSinceLastBan)
+
+	switch {
+	case profile.DaysSinceLastBan < 365:
+		return 1, detail
+	case profile.DaysSinceLastBan < 365*3:
+		return 0.6, detail
+	default:
+		return 0.3, detail
+	}
+}
+
+// cheatingReasons are the keywords that classify a sourceban as being for cheating.
+var cheatingReasons = []string{"cheat", "hack", "aimbot", "wallhack", "triggerbot", "exploit"}
+
+// sourceBanSeverity counts active and historical bans, with cheating bans counting for more than other reasons.
+func sourceBanSeverity(bans []tfapi.SourceBan) (float64, string) {
+	var (
+		severity float64
+		cheating int
+		other    int
+	)
+
+	for _, ban := range bans {
+		weight := 0.25
+
+		reason := strings.ToLower(ban.Reason)
+		if slices.ContainsFunc(cheatingReasons, func(keyword string) bool { return strings.Contains(reason, keyword) }) {
+			weight = 0.5
+			cheating++
+		} else {
+			other++
+		}
+
+		if !ban.Active() {
+			weight /= 2
+		}
+
+		severity += weight
+	}
+
+	if severity == 0 {
+		return 0, ""
+	}
+
+	return severity, fmt.Sprintf("%d cheating, %d other", cheating, other)
+}
+
+// botDetectorSeverity uses the list attributes, since lists also track players that are only suspicious.
+func botDetectorSeverity(matches []tfapi.BotDetectorMatch) (float64, string) {
+	var (
+		severity float64
+		lists    []string
+	)
+
+	for _, match := range matches {
+		lists = append(lists, match.ListName)
+
+		matchSeverity := 0.5
+		for _, attribute := range match.Attributes {
+			switch strings.ToLower(attribute) {
+			case "cheater", "bot":
+				matchSeverity = 1
+			}
+		}
+
+		severity = max(severity, matchSeverity)
+	}
+
+	if severity == 0 {
+		return 0, ""
+	}
+
+	return severity, "Listed on " + strings.Join(lists, ", ")
+}
+
+// negativeReputations are the keywords that classify a steamrep tag as negative, with the severity of each.
+// Other tags, such as those of trusted traders and middlemen, don't add any risk.
+var negativeReputations = map[string]float64{
+	"scammer":      1,
+	"impersonator": 1,
+	"banned":       1,
+	"caution":      0.5,
+}
+
+// steamRepSeverity uses the banned flag and the negative tags, taking the most severe of them.
+func steamRepSeverity(reputations []tfapi.Reputation) (float64, string) {
+	var (
+		severity float64
+		tags     []string
+	)
+
+	for _, reputation := range reputations {
+		if reputation.Banned {
+			severity = 1
+		}
+
+		for _, tag := range reputation.Tags {
+			tagSeverity := 0.0
+			for keyword, keywordSeverity := range negativeReputations {
+				if strings.Contains(strings.ToLower(tag), keyword) {
+					tagSeverity = max(tagSeverity, keywordSeverity)
+				}
+			}
+
+			if tagSeverity > 0 {
+				severity = max(severity, tagSeverity)
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	switch {
+	case severity == 0:
+		return 0, ""
+	case len(tags) == 0:
+		return severity, "Banned"
+	default:
+		return severity, "Tagged: " + strings.Join(tags, ", ")
+	}
+}
+
+// friendSeverity scales with the fraction of friends that have VAC or game bans. A quarter of friends being
+// banned is treated as the maximum.
+func friendSeverity(friends []tfapi.SteamBanState) (float64, string) {
+	if len(friends) == 0 {
+		return 0, ""
+	}
+
+	var banned int
+
+	for _, friend := range friends {
+		if friend.VACBans > 0 || friend.GameBans > 0 {
+			banned++
+		}
+	}
+
+	if banned == 0 {
+		return 0, ""
+	}
+
+	return float64(banned) / float64(len(friends)) * 4, fmt.Sprintf("%d of %d friends banned", banned, len(friends))
+}
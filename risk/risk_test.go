@@ -0,0 +1,237 @@
+package risk
+
+import (
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/leighmacdonald/tf-api-discord/tfapi"
+)
+
+var testNow = time.Date(2025, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+// cleanInput returns an input that doesn't trigger any factor.
+func cleanInput() Input {
+	return Input{
+		Profile: tfapi.Profile{
+			Visibility:  tfapi.VisibilityPublic,
+			LogsCount:   100,
+			TimeCreated: testNow.AddDate(-5, 0, 0),
+		},
+		Now: testNow,
+	}
+}
+
+func TestAssessFactors(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(input *Input)
+		want   map[Factor]int
+		score  int
+	}{
+		{
+			name:   "clean",
+			modify: func(_ *Input) {},
+			want:   map[Factor]int{},
+		},
+		{
+			name: "recent vac ban",
+			modify: func(input *Input) {
+				input.Profile.VACBans, input.Profile.DaysSinceLastBan = 1, 100
+			},
+			want:  map[Factor]int{FactorVACBans: 40},
+			score: 40,
+		},
+		{
+			name: "old vac ban",
+			modify: func(input *Input) {
+				input.Profile.VACBans, input.Profile.DaysSinceLastBan = 2, 2000
+			},
+			want:  map[Factor]int{FactorVACBans: 12},
+			score: 12,
+		},
+		{
+			name:   "game ban",
+			modify: func(input *Input) { input.Profile.GameBans = 1 },
+			want:   map[Factor]int{FactorGameBans: 10},
+			score:  10,
+		},
+		{
+			name:   "community ban",
+			modify: func(input *Input) { input.Profile.CommunityBanned = true },
+			want:   map[Factor]int{FactorCommunityBan: 5},
+			score:  5,
+		},
+		{
+			name:   "economy probation",
+			modify: func(input *Input) { input.Profile.EconomyBan = tfapi.EconomyBanProbation },
+			want:   map[Factor]int{FactorEconomyBan: 3},
+			score:  3,
+		},
+		{
+			name: "sourcebans",
+			modify: func(input *Input) {
+				input.Profile.Bans = []tfapi.SourceBan{
+					{Reason: "Aimbot", Permanent: true},
+					{Reason: "Mic spam", Unbanned: true},
+				}
+			},
+			want:  map[Factor]int{FactorSourceBans: 19},
+			score: 19,
+		},
+		{
+			name: "bot detector cheater",
+			modify: func(input *Input) {
+				input.BotDetector = []tfapi.BotDetectorMatch{{ListName: "pazer", Attributes: []string{"cheater"}}}
+			},
+			want:  map[Factor]int{FactorBotDetector: 40},
+			score: 40,
+		},
+		{
+			name: "bot detector suspicious",
+			modify: func(input *Input) {
+				input.BotDetector = []tfapi.BotDetectorMatch{{ListName: "pazer", Attributes: []string{"suspicious"}}}
+			},
+			want:  map[Factor]int{FactorBotDetector: 20},
+			score: 20,
+		},
+		{
+			name:   "steamrep banned",
+			modify: func(input *Input) { input.Reputations = []tfapi.Reputation{{Banned: true}} },
+			want:   map[Factor]int{FactorSteamRep: 30},
+			score:  30,
+		},
+		{
+			name: "steamrep scammer",
+			modify: func(input *Input) {
+				input.Reputations = []tfapi.Reputation{{Tags: []string{"Trusted Seller", "SR SCAMMER"}}}
+			},
+			want:  map[Factor]int{FactorSteamRep: 30},
+			score: 30,
+		},
+		{
+			name:   "steamrep caution",
+			modify: func(input *Input) { input.Reputations = []tfapi.Reputation{{Tags: []string{"SR Caution"}}} },
+			want:   map[Factor]int{FactorSteamRep: 15},
+			score:  15,
+		},
+		{
+			name: "steamrep positive tags",
+			modify: func(input *Input) {
+				input.Reputations = []tfapi.Reputation{{Tags: []string{"Trusted Seller", "Middleman"}}}
+			},
+			want: map[Factor]int{},
+		},
+		{
+			name: "friend bans",
+			modify: func(input *Input) {
+				input.FriendBans = []tfapi.SteamBanState{{VACBans: 1}, {}, {}, {}, {}, {}, {}, {}}
+			},
+			want:  map[Factor]int{FactorFriendBans: 8},
+			score: 8,
+		},
+		{
+			name:   "new account",
+			modify: func(input *Input) { input.Profile.TimeCreated = testNow.AddDate(0, 0, -10) },
+			want:   map[Factor]int{FactorAccountAge: 10},
+			score:  10,
+		},
+		{
+			name:   "account under a year old",
+			modify: func(input *Input) { input.Profile.TimeCreated = testNow.AddDate(0, -6, 0) },
+			want:   map[Factor]int{FactorAccountAge: 5},
+			score:  5,
+		},
+		{
+			name:   "unknown account age",
+			modify: func(input *Input) { input.Profile.TimeCreated = time.Unix(0, 0) },
+			want:   map[Factor]int{},
+		},
+		{
+			name:   "private profile",
+			modify: func(input *Input) { input.Profile.Visibility = tfapi.VisibilityPrivate },
+			want:   map[Factor]int{FactorPrivateProfile: 10},
+			score:  10,
+		},
+		{
+			name:   "no logs",
+			modify: func(input *Input) { input.Profile.LogsCount = 0 },
+			want:   map[Factor]int{FactorNoLogs: 5},
+			score:  5,
+		},
+		{
+			name: "capped",
+			modify: func(input *Input) {
+				input.Profile.VACBans, input.Profile.DaysSinceLastBan = 1, 10
+				input.BotDetector = []tfapi.BotDetectorMatch{{Attributes: []string{"bot"}}}
+				input.Reputations = []tfapi.Reputation{{Banned: true}}
+			},
+			want:  map[Factor]int{FactorVACBans: 40, FactorBotDetector: 40, FactorSteamRep: 30},
+			score: MaxScore,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			input := cleanInput()
+			test.modify(&input)
+
+			assessment := Assess(input, DefaultWeights())
+
+			got := map[Factor]int{}
+			for _, contribution := range assessment.Breakdown {
+				got[contribution.Factor] = contribution.Points
+			}
+
+			if !maps.Equal(got, test.want) {
+				t.Errorf("expected contributions %v, got %v", test.want, got)
+			}
+
+			if assessment.Score != test.score {
+				t.Errorf("expected score %d, got %d", test.score, assessment.Score)
+			}
+		})
+	}
+}
+
+func TestAssessWeights(t *testing.T) {
+	input := cleanInput()
+	input.Profile.VACBans, input.Profile.DaysSinceLastBan = 1, 10
+	input.Profile.LogsCount = 0
+
+	weights := DefaultWeights().WithOverrides(map[string]int{
+		string(FactorVACBans): 0,
+		string(FactorNoLogs):  20,
+		"unknown":             50,
+	})
+
+	if _, found := weights["unknown"]; found {
+		t.Error("expected unknown factors to be ignored")
+	}
+
+	assessment := Assess(input, weights)
+	if assessment.Score != 20 || len(assessment.Breakdown) != 1 || assessment.Breakdown[0].Factor != FactorNoLogs {
+		t.Errorf("expected only the overridden no logs weight to apply, got %+v", assessment)
+	}
+}
+
+func TestAssessmentLevel(t *testing.T) {
+	tests := []struct {
+		score int
+		want  string
+	}{
+		{0, "None"},
+		{1, "Low"},
+		{24, "Low"},
+		{25, "Medium"},
+		{59, "Medium"},
+		{60, "High"},
+		{MaxScore, "High"},
+	}
+
+	for _, test := range tests {
+		if level := (Assessment{Score: test.score}).Level(); level != test.want {
+			t.Errorf("expected level %s for score %d, got %s", test.want, test.score, level)
+		}
+	}
+}
@@ -0,0 +1,417 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leighmacdonald/discordgo-lipstick/bot"
+	"github.com/leighmacdonald/tf-api-discord/store"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	commandTimeout = time.Second * 30
+	// autocompleteTimeout is kept below the ~3 seconds discord allows for responding to autocomplete.
+	autocompleteTimeout = time.Millisecond * 2500
+)
+
+var (
+	errCommandDisabled = errors.New("command is disabled in this server")
+	errPermission      = errors.New("you do not have permission to use this command")
+)
+
+// reply controls who can see the response to a command by default.
+type reply int
+
+const (
+	// replyPublic responses are visible to everyone in the channel, unless the guild has opted into
+	// ephemeral responses for all commands.
+	replyPublic reply = iota
+	// replyEphemeral responses are only ever visible to the member that invoked the command.
+	replyEphemeral
+)
+
+type settingsKey struct{}
+
+// guildSettings returns the settings of the guild the interaction was invoked within. Interactions
+// outside a guild get the default settings.
+func guildSettings(ctx context.Context) store.GuildSettings {
+	settings, ok := ctx.Value(settingsKey{}).(store.GuildSettings)
+	if !ok {
+		return store.GuildSettings{Timezone: "UTC"}
+	}
+
+	return settings
+}
+
+// withGuildSettings returns a copy of ctx carrying the guild settings, for use by guildSettings.
+func withGuildSettings(ctx context.Context, settings store.GuildSettings) context.Context {
+	return context.WithValue(ctx, settingsKey{}, settings)
+}
+
+// response is the content a command responds with.
+type response struct {
+	embeds []*discordgo.MessageEmbed
+	files  []*discordgo.File
+}
+
+// responder is like bot.Handler, but can respond with multiple embeds and attachments.
+type responder func(ctx context.Context, session *discordgo.Session, interaction *discordgo.InteractionCreate) (response, error)
+
+// embedResponder adapts a handler that responds with a single embed.
+func embedResponder(handler bot.Handler) responder {
+	return func(ctx context.Context, session *discordgo.Session, interaction *discordgo.InteractionCreate) (response, error) {
+		embed, err := handler(ctx, session, interaction)
+		if err != nil || embed == nil {
+			return response{}, err
+		}
+
+		return response{embeds: []*discordgo.MessageEmbed{embed}}, nil
+	}
+}
+
+// command is a registered application command and the handler used to respond to it.
+type command struct {
+	definition *discordgo.ApplicationCommand
+	handler    responder
+	permission permission
+	reply      reply
+}
+
+// router owns the discord session and dispatches interactions to the registered command handlers,
+// applying the guild settings to each of them.
+type router struct {
+	session  *discordgo.Session
+	appID    string
+	guildID  string
+	database *store.Store
+	metrics  *metrics
+	// links are the urls used in the embeds of the responses.
+	links    embedConfig
+	commands map[string]*command
+	// autocomplete handlers keyed by the option name they provide choices for.
+	autocomplete map[string]autocompleteHandler
+	// order preserves the registration order for bulk registration.
+	order []string
+	// connected and registered track the gateway connection and command registration for readiness checks.
+	// Without a gateway connection, connected is set once the interactions endpoint is serving.
+	connected  atomic.Bool
+	registered atomic.Bool
+}
+
+func newRouter(opts bot.Opts, links embedConfig, database *store.Store, metrics *metrics) (*router, error) {
+	if opts.AppID == "" {
+		return nil, fmt.Errorf("%w: invalid discord app id", bot.ErrConfig)
+	}
+
+	if opts.Token == "" {
+		return nil, fmt.Errorf("%w: invalid discord token", bot.ErrConfig)
+	}
+
+	session, errSession := discordgo.New("Bot " + opts.Token)
+	if errSession != nil {
+		return nil, errors.Join(errSession, bot.ErrConfig)
+	}
+
+	session.UserAgent = opts.UserAgent
+	session.Identify.Intents |= discordgo.IntentsGuildMessages
+	session.Identify.Intents |= discordgo.IntentMessageContent
+	session.Identify.Intents |= discordgo.IntentGuildMembers
+
+	router := &router{
+		session:      session,
+		appID:        opts.AppID,
+		guildID:      opts.GuildID,
+		database:     database,
+		metrics:      metrics,
+		links:        links,
+		commands:     map[string]*command{},
+		autocomplete: map[string]autocompleteHandler{},
+	}
+
+	session.AddHandler(router.onReady)
+	session.AddHandler(router.onConnect)
+	session.AddHandler(router.onDisconnect)
+	session.AddHandler(router.onInteractionCreate)
+	session.AddHandler(router.onGuildMemberAdd)
+	session.AddHandler(router.onGuildMemberRemove)
+	session.AddHandler(metrics.onConnect)
+
+	return router, nil
+}
+
+func (r *router) start() error {
+	if errOpen := r.session.Open(); errOpen != nil {
+		return errors.Join(errOpen, bot.ErrSession)
+	}
+
+	return nil
+}
+
+func (r *router) close() {
+	if err := r.session.Close(); err != nil {
+		slog.Error("Failed to close discord session cleanly", slog.String("error", err.Error()))
+	}
+}
+
+// mustRegister adds a command to be registered upon connecting. The default member permissions, contexts and
+// integration types of the command are set according to the permission tier required. Registering the same
+// command name twice will panic.
+func (r *router) mustRegister(perm permission, rep reply, definition *discordgo.ApplicationCommand, handler bot.Handler) {
+	r.mustRegisterResponder(perm, rep, definition, embedResponder(handler))
+}
+
+// mustRegisterResponder is like mustRegister, for commands that respond with more than a single embed.
+func (r *router) mustRegisterResponder(perm permission, rep reply, definition *discordgo.ApplicationCommand, handler responder) {
+	if _, found := r.commands[definition.Name]; found {
+		panic(bot.ErrCommandDuplicate)
+	}
+
+	definition.DefaultMemberPermissions = perm.defaultMemberPermissions()
+	definition.Contexts = perm.contexts()
+	definition.IntegrationTypes = perm.integrationTypes()
+
+	r.commands[definition.Name] = &command{definition: definition, handler: handler, permission: perm, reply: rep}
+	r.order = append(r.order, definition.Name)
+}
+
+// registerAutocomplete sets the handler used to suggest choices for options with the given name. The
+// options must also set Autocomplete in their definition.
+func (r *router) registerAutocomplete(optionName string, handler autocompleteHandler) {
+	r.autocomplete[optionName] = handler
+}
+
+// commandNames returns the names of all registered commands, in the order they were registered.
+func (r *router) commandNames() []string {
+	return r.order
+}
+
+func (r *router) overwriteCommands() error {
+	definitions := make([]*discordgo.ApplicationCommand, len(r.order))
+	for i, name := range r.order {
+		definitions[i] = r.commands[name].definition
+	}
+
+	// When guildID is empty, it registers the commands globally instead of per guild.
+	if _, errBulk := r.session.ApplicationCommandBulkOverwrite(r.appID, r.guildID, definitions); errBulk != nil {
+		return errors.Join(errBulk, bot.ErrCommandInvalid)
+	}
+
+	r.registered.Store(true)
+
+	return nil
+}
+
+func (r *router) onReady(session *discordgo.Session, _ *discordgo.Ready) {
+	slog.Info("Logged in successfully", slog.String("name", session.State.User.Username))
+}
+
+func (r *router) onConnect(_ *discordgo.Session, _ *discordgo.Connect) {
+	slog.Info("Discord state changed", slog.String("state", "connected"))
+	r.connected.Store(true)
+
+	if errRegister := r.overwriteCommands(); errRegister != nil {
+		slog.Error("Failed to register discord slash commands", slog.String("error", errRegister.Error()))
+	}
+}
+
+func (r *router) onDisconnect(_ *discordgo.Session, _ *discordgo.Disconnect) {
+	slog.Info("Discord state changed", slog.String("state", "disconnected"))
+	r.connected.Store(false)
+}
+
+// initialResponder sends the initial response to an interaction. Interactions received over the gateway are
+// responded to with the REST api, while those received by the interactions endpoint are responded to in the
+// body of the webhook request.
+type initialResponder func(resp *discordgo.InteractionResponse) error
+
+func (r *router) onInteractionCreate(session *discordgo.Session, interaction *discordgo.InteractionCreate) {
+	r.handleInteraction(session, interaction, func(resp *discordgo.InteractionResponse) error {
+		return session.InteractionRespond(interaction.Interaction, resp)
+	})
+}
+
+// handleInteraction dispatches the interaction to its command or autocomplete handler. The initial response
+// is always sent with respond, followups are sent using the session.
+func (r *router) handleInteraction(session *discordgo.Session, interaction *discordgo.InteractionCreate, respond initialResponder) {
+	switch interaction.Type {
+	case discordgo.InteractionApplicationCommand:
+	case discordgo.InteractionApplicationCommandAutocomplete:
+		r.onAutocomplete(session, interaction, respond)
+
+		return
+	default:
+		return
+	}
+
+	name := interaction.ApplicationCommandData().Name
+
+	cmd, found := r.commands[name]
+	if !found {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(withEmbedLinks(context.Background(), r.links), commandTimeout)
+	defer cancel()
+
+	ctx, span := tracer.Start(ctx, "interaction "+name, trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("discord.command", name),
+			attribute.String("discord.guild_id", interaction.GuildID),
+			attribute.String("discord.user_id", interactionUserID(interaction)),
+		))
+	defer span.End()
+
+	settings, errSettings := r.database.GuildSettings(ctx, interaction.GuildID)
+	if errSettings != nil {
+		slog.Error("Failed to load guild settings", slog.String("error", errSettings.Error()))
+	}
+
+	r.recordMember(ctx, interaction)
+
+	ctx, targets := withAuditTargets(withGuildSettings(ctx, settings))
+
+	if !settings.CommandEnabled(name) {
+		span.SetAttributes(attribute.String("discord.outcome", outcomeDisabled))
+		r.metrics.observeCommand(name, interaction.GuildID, outcomeDisabled, 0)
+		r.audit(ctx, interaction, targets, outcomeDisabled, nil, 0)
+		r.respondError(ctx, respond, errCommandDisabled)
+
+		return
+	}
+
+	if memberPermission(settings, interaction.Member) < cmd.permission {
+		span.SetAttributes(attribute.String("discord.outcome", outcomeDenied))
+		r.metrics.observeCommand(name, interaction.GuildID, outcomeDenied, 0)
+		r.audit(ctx, interaction, targets, outcomeDenied, nil, 0)
+		r.respondError(ctx, respond, errPermission)
+
+		return
+	}
+
+	var flags discordgo.MessageFlags
+	if cmd.reply == replyEphemeral || settings.Ephemeral {
+		flags = discordgo.MessageFlagsEphemeral
+	}
+
+	// Discord will time out commands that don't respond within ~3 seconds, so we always defer
+	// the response and edit it once the handler completes.
+	_, deferSpan := tracer.Start(ctx, "discord.defer")
+
+	errRespond := respond(&discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: flags},
+	})
+	endSpan(deferSpan, errRespond)
+
+	if errRespond != nil {
+		slog.Error("Failed to send deferred response", slog.String("error", errRespond.Error()),
+			slog.String("command", name))
+		span.SetStatus(codes.Error, errRespond.Error())
+		r.audit(ctx, interaction, targets, outcomeError, errRespond, 0)
+
+		return
+	}
+
+	start := time.Now()
+	handlerCtx, handlerSpan := tracer.Start(ctx, "command "+name)
+	handlerCtx, handlerProgress := withProgress(handlerCtx, session, interaction.Interaction)
+
+	resp, errHandler := cmd.handler(handlerCtx, session, interaction)
+	if errHandler == nil && len(resp.embeds) == 0 && len(resp.files) == 0 {
+		errHandler = fmt.Errorf("%w: empty response", bot.ErrCommandExec)
+	}
+
+	endSpan(handlerSpan, errHandler)
+
+	elapsed := time.Since(start)
+	span.SetAttributes(attribute.String("discord.outcome", commandOutcome(errHandler)))
+	r.metrics.observeCommand(name, interaction.GuildID, commandOutcome(errHandler), elapsed)
+	r.audit(ctx, interaction, targets, commandOutcome(errHandler), errHandler, elapsed)
+
+	if errHandler != nil {
+		slog.Error("Command failed", slog.String("command", name), slog.String("error", errHandler.Error()))
+		resp = response{embeds: []*discordgo.MessageEmbed{errorEmbed(ctx, errHandler)}}
+	}
+
+	// Slow commands may have been showing their progress, which must not overwrite the final response.
+	handlerProgress.finish()
+
+	_, respondSpan := tracer.Start(ctx, "discord.respond")
+
+	_, errEdit := session.InteractionResponseEdit(interaction.Interaction, &discordgo.WebhookEdit{
+		Embeds: &resp.embeds,
+		Files:  resp.files,
+	})
+	endSpan(respondSpan, errEdit)
+
+	if errEdit != nil {
+		slog.Error("Failed to send response", slog.String("error", errEdit.Error()), slog.String("command", name))
+	}
+}
+
+func (r *router) onAutocomplete(session *discordgo.Session, interaction *discordgo.InteractionCreate, respond initialResponder) {
+	data := interaction.ApplicationCommandData()
+
+	option := focusedOption(data.Options)
+	if option == nil {
+		return
+	}
+
+	handler, found := r.autocomplete[option.Name]
+	if !found {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), autocompleteTimeout)
+	defer cancel()
+
+	settings, errSettings := r.database.GuildSettings(ctx, interaction.GuildID)
+	if errSettings != nil {
+		slog.Error("Failed to load guild settings", slog.String("error", errSettings.Error()))
+	}
+
+	value, _ := option.Value.(string)
+
+	choices, errChoices := handler(withGuildSettings(ctx, settings), session, interaction, value)
+	if errChoices != nil {
+		slog.Error("Autocomplete failed", slog.String("command", data.Name), slog.String("error", errChoices.Error()))
+	}
+
+	// Discord expects a non-nil list, even when there is nothing to suggest.
+	if choices == nil {
+		choices = []*discordgo.ApplicationCommandOptionChoice{}
+	}
+
+	if errRespond := respond(&discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{Choices: choices},
+	}); errRespond != nil {
+		slog.Error("Failed to send autocomplete response", slog.String("error", errRespond.Error()),
+			slog.String("command", data.Name))
+	}
+}
+
+// respondError sends an immediate ephemeral error response for interactions rejected before being deferred.
+func (r *router) respondError(ctx context.Context, respond initialResponder, err error) {
+	if errRespond := respond(&discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{errorEmbed(ctx, err)},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	}); errRespond != nil {
+		slog.Error("Failed to send error response", slog.String("error", errRespond.Error()))
+	}
+}
+
+func errorEmbed(ctx context.Context, err error) *discordgo.MessageEmbed {
+	return newEmbed(ctx, "Error").setStatus(statusError).setSource("", time.Time{}).setDescription(err.Error()).build()
+}
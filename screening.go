@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leighmacdonald/steamid/v4/steamid"
+	"github.com/leighmacdonald/tf-api-discord/risk"
+	"github.com/leighmacdonald/tf-api-discord/store"
+	"github.com/leighmacdonald/tf-api-discord/tfapi"
+)
+
+// screener checks members against the api when they join a guild or link their steam account. The risk
+// summary is posted for moderators, and risky members can optionally be given a quarantine role.
+type screener struct {
+	api      *tfapi.TFAPI
+	database *store.Store
+	session  *discordgo.Session
+	links    embedConfig
+}
+
+func newScreener(api *tfapi.TFAPI, database *store.Store, session *discordgo.Session, links embedConfig) *screener {
+	return &screener{api: api, database: database, session: session, links: links}
+}
+
+func (s *screener) onGuildMemberAdd(_ *discordgo.Session, event *discordgo.GuildMemberAdd) {
+	if event.User == nil || event.User.Bot {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(withEmbedLinks(context.Background(), s.links), commandTimeout)
+	defer cancel()
+
+	settings, errSettings := s.database.GuildSettings(ctx, event.GuildID)
+	if errSettings != nil {
+		slog.Error("Failed to load guild settings", slog.String("error", errSettings.Error()))
+
+		return
+	}
+
+	if !settings.ScreeningEnabled {
+		return
+	}
+
+	link, errLink := s.database.AccountLink(ctx, event.User.ID)
+	if errLink != nil {
+		// Members without a linked account are still reported so moderators can ask them to link one, and
+		// members that couldn't be screened so they can be checked manually.
+		description := fmt.Sprintf("<@%s> joined without a linked steam account", event.User.ID)
+		if !errors.Is(errLink, store.ErrNoResult) {
+			slog.Error("Failed to load account link", slog.String("error", errLink.Error()),
+				slog.String("guild_id", event.GuildID), slog.String("user_id", event.User.ID))
+
+			description = fmt.Sprintf("<@%s> joined but could not be screened, failed to load their linked steam account",
+				event.User.ID)
+		}
+
+		embed := newEmbed(withGuildSettings(ctx, settings), "[Screening] "+event.User.Username).
+			setAuthor(event.User.Username, "", event.User.AvatarURL("")).
+			setSource(sourceBot, time.Time{}).
+			setDescription(description)
+		s.post(settings, embed.build())
+
+		return
+	}
+
+	s.screen(ctx, settings, event.User.ID, link.SteamID)
+}
+
+// screen assesses the player and reports the result to the guild.
+func (s *screener) screen(ctx context.Context, settings store.GuildSettings, userID string, steamID steamid.SteamID) {
+	if !settings.ScreeningEnabled {
+		return
+	}
+
+	profile, errProfile := s.api.Profile(ctx, steamID)
+	if errProfile != nil {
+		slog.Error("Failed to fetch screening profile", slog.String("error", errProfile.Error()),
+			slog.String("steam_id", steamID.String()))
+
+		return
+	}
+
+	assessment := assessPlayer(ctx, s.api, settings, profile)
+	quarantined := settings.QuarantineThreshold > 0 && settings.QuarantineRoleID != "" &&
+		assessment.Score >= settings.QuarantineThreshold
+
+	if quarantined {
+		if err := s.session.GuildMemberRoleAdd(settings.GuildID, userID, settings.QuarantineRoleID,
+			discordgo.WithContext(ctx)); err != nil {
+			slog.Error("Failed to assign quarantine role", slog.String("error", err.Error()),
+				slog.String("guild_id", settings.GuildID))
+
+			quarantined = false
+		}
+	}
+
+	s.post(settings, screeningEmbed(withGuildSettings(ctx, settings), userID, profile, assessment, quarantined))
+}
+
+func (s *screener) post(settings store.GuildSettings, embed *discordgo.MessageEmbed) {
+	channelID := settings.ScreeningChannelID
+	if channelID == "" {
+		channelID = settings.AlertChannelID
+	}
+
+	if channelID == "" {
+		slog.Warn("Screening enabled without a channel configured", slog.String("guild_id", settings.GuildID))
+
+		return
+	}
+
+	if _, err := s.session.ChannelMessageSendEmbed(channelID, embed); err != nil {
+		slog.Error("Failed to send screening message", slog.String("error", err.Error()),
+			slog.String("guild_id", settings.GuildID))
+	}
+}
+
+func screeningEmbed(ctx context.Context, userID string, profile tfapi.Profile, assessment risk.Assessment, quarantined bool) *discordgo.MessageEmbed {
+	embed := newEmbed(ctx, "[Screening] "+profile.PersonaName).setProfile(profile).setStatus(profileStatus(profile))
+
+	embed.addFieldInline("Member", "<@"+userID+">")
+	embed.addFieldInline("SteamID", profile.SteamID.String())
+	embed.addFieldInline("Account Created", formatDate(ctx, profile.TimeCreated))
+	embed.addFieldInline("Quarantined", strconv.FormatBool(quarantined))
+	addRiskFields(embed, assessment)
+
+	return embed.build()
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// serveHTTP serves the operational endpoints, such as metrics and health checks, and the interactions
+// endpoint when enabled, until ctx is done.
+func serveHTTP(ctx context.Context, addr string, handler http.Handler) {
+	server := &http.Server{Addr: addr, Handler: handler, ReadHeaderTimeout: time.Second * 10}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		defer cancel()
+
+		if errShutdown := server.Shutdown(shutdownCtx); errShutdown != nil {
+			slog.Error("Failed to shutdown http listener", slog.String("error", errShutdown.Error()))
+		}
+	}()
+
+	slog.Info("Serving http", slog.String("addr", addr))
+
+	if errServe := server.ListenAndServe(); errServe != nil && !errors.Is(errServe, http.ErrServerClosed) {
+		slog.Error("HTTP listener failed", slog.String("error", errServe.Error()))
+	}
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leighmacdonald/discordgo-lipstick/bot"
+	"github.com/leighmacdonald/tf-api-discord/risk"
+	"github.com/leighmacdonald/tf-api-discord/store"
+)
+
+var errInvalidTimezone = errors.New("invalid timezone, expected a IANA name such as Europe/Berlin")
+
+// commandConfig is the name of the config command, it cannot be disabled.
+const commandConfig = "config"
+
+func configCommand(siteNames []*discordgo.ApplicationCommandOptionChoice, commandNames []string) *discordgo.ApplicationCommand {
+	var commandChoices []*discordgo.ApplicationCommandOptionChoice
+	for _, name := range commandNames {
+		if name == commandConfig {
+			continue
+		}
+
+		commandChoices = append(commandChoices, &discordgo.ApplicationCommandOptionChoice{Name: name, Value: name})
+	}
+
+	var factorChoices []*discordgo.ApplicationCommandOptionChoice
+	for _, factor := range risk.Factors() {
+		factorChoices = append(factorChoices, &discordgo.ApplicationCommandOptionChoice{Name: string(factor), Value: string(factor)})
+	}
+
+	var themeChoices []*discordgo.ApplicationCommandOptionChoice
+	for _, name := range themeNames() {
+		themeChoices = append(themeChoices, &discordgo.ApplicationCommandOptionChoice{Name: name, Value: name})
+	}
+
+	minZero := float64(0)
+
+	return &discordgo.ApplicationCommand{
+		Name:        commandConfig,
+		Description: "Configure the bot for this server",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "show",
+				Description: "Show the current configuration",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+			},
+			{
+				Name:        "alert_channel",
+				Description: "Set the channel that alerts such as watchlist changes are posted to",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:         "channel",
+						Description:  "Channel to post alerts to",
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+						Required:     true,
+					},
+				},
+			},
+			{
+				Name:        "mod_role",
+				Description: "Set the role allowed to use moderator commands",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        "role",
+						Description: "Moderator role",
+						Type:        discordgo.ApplicationCommandOptionRole,
+						Required:    true,
+					},
+				},
+			},
+			{
+				Name:        "admin_role",
+				Description: "Set the role allowed to use admin commands",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        "role",
+						Description: "Admin role",
+						Type:        discordgo.ApplicationCommandOptionRole,
+						Required:    true,
+					},
+				},
+			},
+			{
+				Name:        "default_site",
+				Description: "Set the site used to filter bans by default, leave empty to show all sites",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        "site",
+						Description: "Site to filter bans by",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Choices:     siteNames,
+						Required:    false,
+					},
+				},
+			},
+			{
+				Name:        "command",
+				Description: "Enable or disable a command",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        "name",
+						Description: "Command name",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Choices:     commandChoices,
+						Required:    true,
+					},
+					{
+						Name:        "enabled",
+						Description: "Whether the command can be used",
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Required:    true,
+					},
+				},
+			},
+			{
+				Name:        "ephemeral",
+				Description: "Only show responses to the user who invoked the command",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        "enabled",
+						Description: "Whether responses are ephemeral by default",
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Required:    true,
+					},
+				},
+			},
+			{
+				Name:        "screening",
+				Description: "Screen members when they join or link their steam account",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        "enabled",
+						Description: "Whether members are screened",
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Required:    true,
+					},
+					{
+						Name:         "channel",
+						Description:  "Channel to post results to, defaults to the alert channel",
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+						Required:     false,
+					},
+					{
+						Name:        "quarantine_role",
+						Description: "Role given to members at or above the risk threshold",
+						Type:        discordgo.ApplicationCommandOptionRole,
+						Required:    false,
+					},
+					{
+						Name:        "threshold",
+						Description: "Risk score (1-100) at which members are quarantined, 0 to disable",
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						MinValue:    &minZero,
+						MaxValue:    risk.MaxScore,
+						Required:    false,
+					},
+					{
+						Name:        "min_account_age",
+						Description: "Flag steam accounts younger than this many days, 0 to disable",
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						MinValue:    &minZero,
+						Required:    false,
+					},
+				},
+			},
+			{
+				Name:        "risk_weight",
+				Description: "Set the maximum points a factor adds to the risk score",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        "factor",
+						Description: "Risk factor",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Choices:     factorChoices,
+						Required:    true,
+					},
+					{
+						Name:        "weight",
+						Description: "Maximum points, 0 to ignore the factor",
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						MinValue:    &minZero,
+						MaxValue:    risk.MaxScore,
+						Required:    true,
+					},
+				},
+			},
+			{
+				Name:        "theme",
+				Description: "Set the colors and icons used by responses",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        "theme",
+						Description: "Theme name",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Choices:     themeChoices,
+						Required:    true,
+					},
+				},
+			},
+			{
+				Name:        "timezone",
+				Description: "Set the timezone used when displaying dates",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        "timezone",
+						Description: "IANA timezone name, eg: America/New_York",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    true,
+					},
+				},
+			},
+		},
+	}
+}
+
+func onConfig(database *store.Store) bot.Handler {
+	return func(ctx context.Context, _ *discordgo.Session, interaction *discordgo.InteractionCreate) (*discordgo.MessageEmbed, error) {
+		name, opts := subCommand(interaction)
+		if name == "show" {
+			return settingsEmbed(ctx, "[Config] Current", guildSettings(ctx)), nil
+		}
+
+		// The settings are reloaded when updating, as the ones loaded for the command may be outdated by the
+		// time they are saved if another update happens at the same time.
+		settings, errUpdate := database.UpdateGuildSettings(ctx, interaction.GuildID, func(current *store.GuildSettings) error {
+			return updateSettings(current, name, opts)
+		})
+		if errUpdate != nil {
+			if errors.Is(errUpdate, bot.ErrCommandInvalid) || errors.Is(errUpdate, errInvalidTimezone) {
+				return nil, errUpdate
+			}
+
+			return nil, errors.Join(errUpdate, bot.ErrCommandExec)
+		}
+
+		return settingsEmbed(ctx, "[Config] Updated", settings), nil
+	}
+}
+
+// updateSettings applies the config sub command to the settings.
+func updateSettings(settings *store.GuildSettings, name string, opts bot.CommandOptions) error {
+	switch name {
+	case "alert_channel":
+		settings.AlertChannelID = opts.String("channel")
+	case "mod_role":
+		settings.ModRoleID = opts.String("role")
+	case "admin_role":
+		settings.AdminRoleID = opts.String("role")
+	case "default_site":
+		settings.DefaultSite = opts.String("site")
+	case "command":
+		settings.SetCommandEnabled(opts.String("name"), optionBool(opts, "enabled"))
+	case "ephemeral":
+		settings.Ephemeral = optionBool(opts, "enabled")
+	case "screening":
+		settings.ScreeningEnabled = optionBool(opts, "enabled")
+		if _, found := opts["channel"]; found {
+			settings.ScreeningChannelID = opts.String("channel")
+		}
+
+		if _, found := opts["quarantine_role"]; found {
+			settings.QuarantineRoleID = opts.String("quarantine_role")
+		}
+
+		if threshold, found := opts["threshold"]; found {
+			settings.QuarantineThreshold = int(threshold.IntValue())
+		}
+
+		if age, found := opts["min_account_age"]; found {
+			settings.MinAccountAge = int(age.IntValue())
+		}
+	case "risk_weight":
+		if settings.RiskWeights == nil {
+			settings.RiskWeights = map[string]int{}
+		}
+
+		settings.RiskWeights[opts.String("factor")] = int(opts["weight"].IntValue())
+	case "theme":
+		settings.Theme = opts.String("theme")
+	case "timezone":
+		if _, errLocation := time.LoadLocation(opts.String("timezone")); errLocation != nil {
+			return errInvalidTimezone
+		}
+
+		settings.Timezone = opts.String("timezone")
+	default:
+		return fmt.Errorf("%w: unknown sub command: %s", bot.ErrCommandInvalid, name)
+	}
+
+	return nil
+}
+
+// settingsEmbed shows the settings using their own theme, so a theme change is visible immediately.
+func settingsEmbed(ctx context.Context, title string, settings store.GuildSettings) *discordgo.MessageEmbed {
+	embed := newEmbed(withGuildSettings(ctx, settings), title).setSource(sourceBot, time.Time{})
+
+	embed.addFieldInline("Alert Channel", mentionOrNone("<#%s>", settings.AlertChannelID))
+	embed.addFieldInline("Mod Role", mentionOrNone("<@&%s>", settings.ModRoleID))
+	embed.addFieldInline("Admin Role", mentionOrNone("<@&%s>", settings.AdminRoleID))
+	embed.addFieldInline("Default Site", valueOrNone(settings.DefaultSite))
+	embed.addFieldInline("Disabled Commands", valueOrNone(strings.Join(settings.DisabledCommands, ", ")))
+	embed.addFieldInline("Ephemeral", strconv.FormatBool(settings.Ephemeral))
+	embed.addFieldInline("Timezone", settings.Timezone)
+	embed.addFieldInline("Theme", cmp.Or(settings.Theme, defaultTheme))
+	embed.addFieldInline("Screening", strconv.FormatBool(settings.ScreeningEnabled))
+	embed.addFieldInline("Screening Channel", mentionOrNone("<#%s>", settings.ScreeningChannelID))
+	embed.addFieldInline("Quarantine Role", mentionOrNone("<@&%s>", settings.QuarantineRoleID))
+	embed.addFieldInline("Quarantine Threshold", strconv.Itoa(settings.QuarantineThreshold))
+	embed.addFieldInline("Min Account Age", fmt.Sprintf("%d days", settings.MinAccountAge))
+
+	var weights []string
+	for factor, weight := range risk.DefaultWeights().WithOverrides(settings.RiskWeights) {
+		weights = append(weights, fmt.Sprintf("%s: %d", factor, weight))
+	}
+
+	slices.Sort(weights)
+	embed.addFieldInline("Risk Weights", strings.Join(weights, "\n"))
+
+	return embed.build()
+}
+
+func mentionOrNone(format string, id string) string {
+	if id == "" {
+		return "None"
+	}
+
+	return fmt.Sprintf(format, id)
+}
+
+func valueOrNone(value string) string {
+	if value == "" {
+		return "None"
+	}
+
+	return value
+}
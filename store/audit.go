@@ -0,0 +1,160 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/leighmacdonald/steamid/v4/steamid"
+)
+
+// AuditEntry records a single command invocation, who ran it and which players it was about.
+type AuditEntry struct {
+	AuditID int64
+	GuildID string
+	UserID  string
+	// Command includes the sub command, if any, such as "watch add".
+	Command string
+	Options map[string]string
+	// SteamIDs are the players the command looked up.
+	SteamIDs  []steamid.SteamID
+	Status    string
+	Error     string
+	Duration  time.Duration
+	CreatedOn time.Time
+}
+
+// AuditFilter selects audit entries, empty fields match everything.
+type AuditFilter struct {
+	GuildID string
+	UserID  string
+	SteamID steamid.SteamID
+	// After and Before limit the entries to those created within the range.
+	After  time.Time
+	Before time.Time
+	Limit  int
+}
+
+// AddAuditEntry records a new entry in the audit log.
+func (s *Store) AddAuditEntry(ctx context.Context, entry *AuditEntry) error {
+	if entry.CreatedOn.IsZero() {
+		entry.CreatedOn = time.Now()
+	}
+
+	options, errOptions := json.Marshal(entry.Options)
+	if errOptions != nil {
+		return dbErr(errOptions)
+	}
+
+	txn, errTx := s.db.BeginTx(ctx, nil)
+	if errTx != nil {
+		return dbErr(errTx)
+	}
+
+	if err := txn.QueryRowContext(ctx, `
+		INSERT INTO audit_log (guild_id, user_id, command, options, status, error, duration_ms, created_on)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING audit_id`,
+		entry.GuildID, entry.UserID, entry.Command, string(options), entry.Status, entry.Error,
+		entry.Duration.Milliseconds(), entry.CreatedOn.Unix()).
+		Scan(&entry.AuditID); err != nil {
+		_ = txn.Rollback()
+
+		return dbErr(err)
+	}
+
+	for _, steamID := range entry.SteamIDs {
+		if _, err := txn.ExecContext(ctx, `
+			INSERT INTO audit_targets (audit_id, steam_id) VALUES (?, ?)
+			ON CONFLICT DO NOTHING`,
+			entry.AuditID, steamID.Int64()); err != nil {
+			_ = txn.Rollback()
+
+			return dbErr(err)
+		}
+	}
+
+	return dbErr(txn.Commit())
+}
+
+// AuditEntries returns the entries matching the filter, newest first.
+func (s *Store) AuditEntries(ctx context.Context, filter AuditFilter) ([]AuditEntry, error) {
+	var (
+		conditions = []string{"1 = 1"}
+		args       []any
+	)
+
+	if filter.GuildID != "" {
+		conditions = append(conditions, "a.guild_id = ?")
+		args = append(args, filter.GuildID)
+	}
+
+	if filter.UserID != "" {
+		conditions = append(conditions, "a.user_id = ?")
+		args = append(args, filter.UserID)
+	}
+
+	if filter.SteamID.Valid() {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM audit_targets t WHERE t.audit_id = a.audit_id AND t.steam_id = ?)")
+		args = append(args, filter.SteamID.Int64())
+	}
+
+	if !filter.After.IsZero() {
+		conditions = append(conditions, "a.created_on >= ?")
+		args = append(args, filter.After.Unix())
+	}
+
+	if !filter.Before.IsZero() {
+		conditions = append(conditions, "a.created_on < ?")
+		args = append(args, filter.Before.Unix())
+	}
+
+	args = append(args, filter.Limit)
+
+	rows, errRows := s.db.QueryContext(ctx, `
+		SELECT a.audit_id, a.guild_id, a.user_id, a.command, a.options, a.status, a.error, a.duration_ms,
+		       a.created_on,
+		       (SELECT COALESCE(GROUP_CONCAT(t.steam_id), '') FROM audit_targets t WHERE t.audit_id = a.audit_id)
+		FROM audit_log a
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY a.created_on DESC, a.audit_id DESC
+		LIMIT ?`, args...)
+	if errRows != nil {
+		return nil, dbErr(errRows)
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+
+	for rows.Next() {
+		var (
+			entry      AuditEntry
+			options    string
+			durationMS int64
+			createdOn  int64
+			steamIDs   string
+		)
+
+		if err := rows.Scan(&entry.AuditID, &entry.GuildID, &entry.UserID, &entry.Command, &options,
+			&entry.Status, &entry.Error, &durationMS, &createdOn, &steamIDs); err != nil {
+			return nil, dbErr(err)
+		}
+
+		if err := json.Unmarshal([]byte(options), &entry.Options); err != nil {
+			return nil, dbErr(err)
+		}
+
+		for _, value := range strings.Split(steamIDs, ",") {
+			if steamID := steamid.New(value); steamID.Valid() {
+				entry.SteamIDs = append(entry.SteamIDs, steamID)
+			}
+		}
+
+		entry.Duration = time.Duration(durationMS) * time.Millisecond
+		entry.CreatedOn = time.Unix(createdOn, 0)
+		entries = append(entries, entry)
+	}
+
+	return entries, dbErr(rows.Err())
+}
@@ -0,0 +1,170 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/leighmacdonald/steamid/v4/steamid"
+)
+
+// BanFeed is a guilds subscription to new bans from a single site.
+type BanFeed struct {
+	GuildID   string
+	SiteName  string
+	ChannelID string
+	CreatedOn time.Time
+}
+
+// AnnouncedBan records a ban that has already been posted to a guild.
+type AnnouncedBan struct {
+	GuildID     string
+	SiteName    string
+	SteamID     steamid.SteamID
+	BanCreated  time.Time
+	AnnouncedOn time.Time
+}
+
+// SaveBanFeed creates or updates the subscription of a guild to a site. Changing the channel
+// of an existing subscription does not reset when it was created.
+func (s *Store) SaveBanFeed(ctx context.Context, feed *BanFeed) error {
+	if feed.CreatedOn.IsZero() {
+		feed.CreatedOn = time.Now()
+	}
+
+	_, errExec := s.db.ExecContext(ctx, `
+		INSERT INTO ban_feeds (guild_id, site_name, channel_id, created_on)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (guild_id, site_name) DO UPDATE SET channel_id = excluded.channel_id`,
+		feed.GuildID, feed.SiteName, feed.ChannelID, feed.CreatedOn.Unix())
+
+	return dbErr(errExec)
+}
+
+// RemoveBanFeed unsubscribes a guild from a site, and forgets which players were seeded so subscribing again
+// doesn't post the bans made in between. ErrNoResult is returned if the guild was not subscribed.
+func (s *Store) RemoveBanFeed(ctx context.Context, guildID string, siteName string) error {
+	txn, errTx := s.db.BeginTx(ctx, nil)
+	if errTx != nil {
+		return dbErr(errTx)
+	}
+
+	result, errExec := txn.ExecContext(ctx, `DELETE FROM ban_feeds WHERE guild_id = ? AND site_name = ?`,
+		guildID, siteName)
+	if errExec != nil {
+		_ = txn.Rollback()
+
+		return dbErr(errExec)
+	}
+
+	affected, errAffected := result.RowsAffected()
+	if errAffected != nil {
+		_ = txn.Rollback()
+
+		return dbErr(errAffected)
+	}
+
+	if affected == 0 {
+		_ = txn.Rollback()
+
+		return ErrNoResult
+	}
+
+	if _, err := txn.ExecContext(ctx, `DELETE FROM ban_feed_players WHERE guild_id = ? AND site_name = ?`,
+		guildID, siteName); err != nil {
+		_ = txn.Rollback()
+
+		return dbErr(err)
+	}
+
+	return dbErr(txn.Commit())
+}
+
+// BanFeeds returns the site subscriptions for a guild. If guildID is empty, the subscriptions for all
+// guilds are returned.
+func (s *Store) BanFeeds(ctx context.Context, guildID string) ([]BanFeed, error) {
+	rows, errRows := s.db.QueryContext(ctx, `
+		SELECT guild_id, site_name, channel_id, created_on
+		FROM ban_feeds
+		WHERE ? = '' OR guild_id = ?
+		ORDER BY guild_id, site_name`, guildID, guildID)
+	if errRows != nil {
+		return nil, dbErr(errRows)
+	}
+	defer rows.Close()
+
+	var feeds []BanFeed
+
+	for rows.Next() {
+		var (
+			feed      BanFeed
+			createdOn int64
+		)
+
+		if err := rows.Scan(&feed.GuildID, &feed.SiteName, &feed.ChannelID, &createdOn); err != nil {
+			return nil, dbErr(err)
+		}
+
+		feed.CreatedOn = time.Unix(createdOn, 0)
+		feeds = append(feeds, feed)
+	}
+
+	return feeds, dbErr(rows.Err())
+}
+
+// BanFeedSeeded checks if the bans the player already had when they were first checked for the subscription
+// have been recorded.
+func (s *Store) BanFeedSeeded(ctx context.Context, feed BanFeed, steamID steamid.SteamID) (bool, error) {
+	var count int
+	if err := s.db.QueryRowContext(ctx, `
+		SELECT count(*)
+		FROM ban_feed_players
+		WHERE guild_id = ? AND site_name = ? AND steam_id = ?`,
+		feed.GuildID, feed.SiteName, steamID.Int64()).
+		Scan(&count); err != nil {
+		return false, dbErr(err)
+	}
+
+	return count > 0, nil
+}
+
+// SetBanFeedSeeded records that the existing bans of the player have been recorded for the subscription, so
+// any bans found after this are announced.
+func (s *Store) SetBanFeedSeeded(ctx context.Context, feed BanFeed, steamID steamid.SteamID) error {
+	_, errExec := s.db.ExecContext(ctx, `
+		INSERT INTO ban_feed_players (guild_id, site_name, steam_id, seeded_on)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT DO NOTHING`,
+		feed.GuildID, feed.SiteName, steamID.Int64(), time.Now().Unix())
+
+	return dbErr(errExec)
+}
+
+// BanAnnounced checks if the ban has already been posted to the guild.
+func (s *Store) BanAnnounced(ctx context.Context, ban AnnouncedBan) (bool, error) {
+	var count int
+	if err := s.db.QueryRowContext(ctx, `
+		SELECT count(*)
+		FROM announced_bans
+		WHERE guild_id = ? AND site_name = ? AND steam_id = ? AND ban_created = ?`,
+		ban.GuildID, ban.SiteName, ban.SteamID.Int64(), ban.BanCreated.Unix()).
+		Scan(&count); err != nil {
+		return false, dbErr(err)
+	}
+
+	return count > 0, nil
+}
+
+// AddAnnouncedBan records that the ban has been posted to the guild.
+func (s *Store) AddAnnouncedBan(ctx context.Context, ban *AnnouncedBan) error {
+	if ban.AnnouncedOn.IsZero() {
+		ban.AnnouncedOn = time.Now()
+	}
+
+	_, errExec := s.db.ExecContext(ctx, `
+		INSERT INTO announced_bans (guild_id, site_name, steam_id, ban_created, announced_on)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT DO NOTHING`,
+		ban.GuildID, ban.SiteName, ban.SteamID.Int64(), ban.BanCreated.Unix(), ban.AnnouncedOn.Unix())
+
+	return dbErr(errExec)
+}
@@ -0,0 +1,69 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/leighmacdonald/steamid/v4/steamid"
+)
+
+// Lookup records a user looking up a player.
+type Lookup struct {
+	LookupID    int64
+	GuildID     string
+	UserID      string
+	Command     string
+	SteamID     steamid.SteamID
+	PersonaName string
+	CreatedOn   time.Time
+}
+
+// AddLookup records a new entry in the lookup history.
+func (s *Store) AddLookup(ctx context.Context, lookup *Lookup) error {
+	if lookup.CreatedOn.IsZero() {
+		lookup.CreatedOn = time.Now()
+	}
+
+	errQuery := s.db.QueryRowContext(ctx, `
+		INSERT INTO lookup_history (guild_id, user_id, command, steam_id, persona_name, created_on)
+		VALUES (?, ?, ?, ?, ?, ?)
+		RETURNING lookup_id`,
+		lookup.GuildID, lookup.UserID, lookup.Command, lookup.SteamID.Int64(), lookup.PersonaName,
+		lookup.CreatedOn.Unix()).
+		Scan(&lookup.LookupID)
+
+	return dbErr(errQuery)
+}
+
+// Lookups returns the most recent lookups performed by a user, newest first.
+func (s *Store) Lookups(ctx context.Context, userID string, limit int) ([]Lookup, error) {
+	rows, errRows := s.db.QueryContext(ctx, `
+		SELECT lookup_id, guild_id, user_id, command, steam_id, persona_name, created_on
+		FROM lookup_history
+		WHERE user_id = ?
+		ORDER BY created_on DESC, lookup_id DESC
+		LIMIT ?`, userID, limit)
+	if errRows != nil {
+		return nil, dbErr(errRows)
+	}
+	defer rows.Close()
+
+	var lookups []Lookup
+
+	for rows.Next() {
+		var (
+			lookup    Lookup
+			createdOn int64
+		)
+
+		if err := rows.Scan(&lookup.LookupID, &lookup.GuildID, &lookup.UserID, &lookup.Command,
+			&lookup.SteamID, &lookup.PersonaName, &createdOn); err != nil {
+			return nil, dbErr(err)
+		}
+
+		lookup.CreatedOn = time.Unix(createdOn, 0)
+		lookups = append(lookups, lookup)
+	}
+
+	return lookups, dbErr(rows.Err())
+}
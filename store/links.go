@@ -0,0 +1,215 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/leighmacdonald/steamid/v4/steamid"
+)
+
+// AccountLink maps a discord user to the steam account they own.
+type AccountLink struct {
+	UserID  string
+	SteamID steamid.SteamID
+	// LinkedBy is the user that created the link. This is the user themselves when verified
+	// through /link, or the admin that overrode it.
+	LinkedBy  string
+	CreatedOn time.Time
+}
+
+// AccountLink fetches the steam account linked to a discord user. ErrNoResult is returned if the
+// user has not linked an account.
+func (s *Store) AccountLink(ctx context.Context, userID string) (AccountLink, error) {
+	var (
+		link      = AccountLink{UserID: userID}
+		createdOn int64
+	)
+
+	if err := s.db.QueryRowContext(ctx, `
+		SELECT steam_id, linked_by, created_on FROM account_links WHERE user_id = ?`, userID).
+		Scan(&link.SteamID, &link.LinkedBy, &createdOn); err != nil {
+		return link, dbErr(err)
+	}
+
+	link.CreatedOn = time.Unix(createdOn, 0)
+
+	return link, nil
+}
+
+// AccountLinks returns every account link.
+func (s *Store) AccountLinks(ctx context.Context) ([]AccountLink, error) {
+	rows, errRows := s.db.QueryContext(ctx, `
+		SELECT user_id, steam_id, linked_by, created_on FROM account_links ORDER BY created_on`)
+	if errRows != nil {
+		return nil, dbErr(errRows)
+	}
+	defer rows.Close()
+
+	var links []AccountLink
+
+	for rows.Next() {
+		var (
+			link      AccountLink
+			createdOn int64
+		)
+
+		if err := rows.Scan(&link.UserID, &link.SteamID, &link.LinkedBy, &createdOn); err != nil {
+			return nil, dbErr(err)
+		}
+
+		link.CreatedOn = time.Unix(createdOn, 0)
+		links = append(links, link)
+	}
+
+	return links, dbErr(rows.Err())
+}
+
+// GuildAccountLinks returns the account links of the users recorded as members of the guild.
+func (s *Store) GuildAccountLinks(ctx context.Context, guildID string) ([]AccountLink, error) {
+	rows, errRows := s.db.QueryContext(ctx, `
+		SELECT l.user_id, l.steam_id, l.linked_by, l.created_on
+		FROM account_links l
+		JOIN guild_members m ON m.user_id = l.user_id
+		WHERE m.guild_id = ?
+		ORDER BY l.created_on`, guildID)
+	if errRows != nil {
+		return nil, dbErr(errRows)
+	}
+	defer rows.Close()
+
+	var links []AccountLink
+
+	for rows.Next() {
+		var (
+			link      AccountLink
+			createdOn int64
+		)
+
+		if err := rows.Scan(&link.UserID, &link.SteamID, &link.LinkedBy, &createdOn); err != nil {
+			return nil, dbErr(err)
+		}
+
+		link.CreatedOn = time.Unix(createdOn, 0)
+		links = append(links, link)
+	}
+
+	return links, dbErr(rows.Err())
+}
+
+// AddGuildMember records that the user is a member of the guild.
+func (s *Store) AddGuildMember(ctx context.Context, guildID string, userID string) error {
+	_, errExec := s.db.ExecContext(ctx, `
+		INSERT INTO guild_members (guild_id, user_id, updated_on)
+		VALUES (?, ?, ?)
+		ON CONFLICT (guild_id, user_id) DO UPDATE SET updated_on = excluded.updated_on`,
+		guildID, userID, time.Now().Unix())
+
+	return dbErr(errExec)
+}
+
+// RemoveGuildMember records that the user has left the guild.
+func (s *Store) RemoveGuildMember(ctx context.Context, guildID string, userID string) error {
+	_, errExec := s.db.ExecContext(ctx, `DELETE FROM guild_members WHERE guild_id = ? AND user_id = ?`,
+		guildID, userID)
+
+	return dbErr(errExec)
+}
+
+// SaveAccountLink links a discord user to a steam account, replacing any existing link. Any pending
+// link token for the user is removed.
+func (s *Store) SaveAccountLink(ctx context.Context, link *AccountLink) error {
+	if link.CreatedOn.IsZero() {
+		link.CreatedOn = time.Now()
+	}
+
+	txn, errTx := s.db.BeginTx(ctx, nil)
+	if errTx != nil {
+		return dbErr(errTx)
+	}
+
+	if _, err := txn.ExecContext(ctx, `
+		INSERT INTO account_links (user_id, steam_id, linked_by, created_on)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET
+			steam_id = excluded.steam_id,
+			linked_by = excluded.linked_by,
+			created_on = excluded.created_on`,
+		link.UserID, link.SteamID.Int64(), link.LinkedBy, link.CreatedOn.Unix()); err != nil {
+		_ = txn.Rollback()
+
+		return dbErr(err)
+	}
+
+	if _, err := txn.ExecContext(ctx, `DELETE FROM link_tokens WHERE user_id = ?`, link.UserID); err != nil {
+		_ = txn.Rollback()
+
+		return dbErr(err)
+	}
+
+	return dbErr(txn.Commit())
+}
+
+// RemoveAccountLink removes the link for a discord user. ErrNoResult is returned if the user has not
+// linked an account.
+func (s *Store) RemoveAccountLink(ctx context.Context, userID string) error {
+	result, errExec := s.db.ExecContext(ctx, `DELETE FROM account_links WHERE user_id = ?`, userID)
+	if errExec != nil {
+		return dbErr(errExec)
+	}
+
+	affected, errAffected := result.RowsAffected()
+	if errAffected != nil {
+		return dbErr(errAffected)
+	}
+
+	if affected == 0 {
+		return ErrNoResult
+	}
+
+	return nil
+}
+
+// LinkToken is a pending request by a user to link a steam account. The token must be placed on the steam
+// profile to prove ownership before the link is created.
+type LinkToken struct {
+	UserID    string
+	SteamID   steamid.SteamID
+	Token     string
+	CreatedOn time.Time
+}
+
+// SaveLinkToken creates a pending link request, replacing any existing request by the user.
+func (s *Store) SaveLinkToken(ctx context.Context, token *LinkToken) error {
+	if token.CreatedOn.IsZero() {
+		token.CreatedOn = time.Now()
+	}
+
+	_, errExec := s.db.ExecContext(ctx, `
+		INSERT INTO link_tokens (user_id, steam_id, token, created_on)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET
+			steam_id = excluded.steam_id,
+			token = excluded.token,
+			created_on = excluded.created_on`,
+		token.UserID, token.SteamID.Int64(), token.Token, token.CreatedOn.Unix())
+
+	return dbErr(errExec)
+}
+
+// LinkToken fetches the pending link request for a user. ErrNoResult is returned if there is none.
+func (s *Store) LinkToken(ctx context.Context, userID string) (LinkToken, error) {
+	var (
+		token     = LinkToken{UserID: userID}
+		createdOn int64
+	)
+
+	if err := s.db.QueryRowContext(ctx, `
+		SELECT steam_id, token, created_on FROM link_tokens WHERE user_id = ?`, userID).
+		Scan(&token.SteamID, &token.Token, &createdOn); err != nil {
+		return token, dbErr(err)
+	}
+
+	token.CreatedOn = time.Unix(createdOn, 0)
+
+	return token, nil
+}
@@ -0,0 +1,39 @@
+CREATE TABLE guild_settings
+(
+    guild_id         TEXT    NOT NULL PRIMARY KEY,
+    alert_channel_id TEXT    NOT NULL DEFAULT '',
+    created_on       INTEGER NOT NULL,
+    updated_on       INTEGER NOT NULL
+);
+
+CREATE TABLE watchlist
+(
+    guild_id   TEXT    NOT NULL,
+    steam_id   INTEGER NOT NULL,
+    note       TEXT    NOT NULL DEFAULT '',
+    added_by   TEXT    NOT NULL,
+    created_on INTEGER NOT NULL,
+    PRIMARY KEY (guild_id, steam_id)
+);
+
+CREATE TABLE lookup_history
+(
+    lookup_id    INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    guild_id     TEXT    NOT NULL DEFAULT '',
+    user_id      TEXT    NOT NULL,
+    command      TEXT    NOT NULL,
+    steam_id     INTEGER NOT NULL,
+    persona_name TEXT    NOT NULL DEFAULT '',
+    created_on   INTEGER NOT NULL
+);
+
+CREATE INDEX lookup_history_user_idx ON lookup_history (user_id, created_on);
+
+CREATE TABLE snapshots
+(
+    steam_id   INTEGER NOT NULL,
+    kind       TEXT    NOT NULL,
+    data       BLOB    NOT NULL,
+    updated_on INTEGER NOT NULL,
+    PRIMARY KEY (steam_id, kind)
+);
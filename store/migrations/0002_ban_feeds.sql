@@ -0,0 +1,18 @@
+CREATE TABLE ban_feeds
+(
+    guild_id   TEXT    NOT NULL,
+    site_name  TEXT    NOT NULL,
+    channel_id TEXT    NOT NULL,
+    created_on INTEGER NOT NULL,
+    PRIMARY KEY (guild_id, site_name)
+);
+
+CREATE TABLE announced_bans
+(
+    guild_id     TEXT    NOT NULL,
+    site_name    TEXT    NOT NULL,
+    steam_id     INTEGER NOT NULL,
+    ban_created  INTEGER NOT NULL,
+    announced_on INTEGER NOT NULL,
+    PRIMARY KEY (guild_id, site_name, steam_id, ban_created)
+);
@@ -0,0 +1,5 @@
+ALTER TABLE guild_settings ADD COLUMN mod_role_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE guild_settings ADD COLUMN default_site TEXT NOT NULL DEFAULT '';
+ALTER TABLE guild_settings ADD COLUMN disabled_commands TEXT NOT NULL DEFAULT '';
+ALTER TABLE guild_settings ADD COLUMN ephemeral INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE guild_settings ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC';
@@ -0,0 +1 @@
+ALTER TABLE guild_settings ADD COLUMN admin_role_id TEXT NOT NULL DEFAULT '';
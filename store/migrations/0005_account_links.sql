@@ -0,0 +1,9 @@
+CREATE TABLE account_links
+(
+    user_id    TEXT    NOT NULL PRIMARY KEY,
+    steam_id   INTEGER NOT NULL,
+    linked_by  TEXT    NOT NULL,
+    created_on INTEGER NOT NULL
+);
+
+CREATE INDEX account_links_steam_idx ON account_links (steam_id);
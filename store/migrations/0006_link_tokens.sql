@@ -0,0 +1,7 @@
+CREATE TABLE link_tokens
+(
+    user_id    TEXT    NOT NULL PRIMARY KEY,
+    steam_id   INTEGER NOT NULL,
+    token      TEXT    NOT NULL,
+    created_on INTEGER NOT NULL
+);
@@ -0,0 +1,5 @@
+ALTER TABLE guild_settings ADD COLUMN screening_enabled INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE guild_settings ADD COLUMN screening_channel_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE guild_settings ADD COLUMN quarantine_role_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE guild_settings ADD COLUMN quarantine_threshold INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE guild_settings ADD COLUMN min_account_age INTEGER NOT NULL DEFAULT 0;
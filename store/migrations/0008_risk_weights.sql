@@ -0,0 +1 @@
+ALTER TABLE guild_settings ADD COLUMN risk_weights TEXT NOT NULL DEFAULT '{}';
@@ -0,0 +1 @@
+ALTER TABLE guild_settings ADD COLUMN theme TEXT NOT NULL DEFAULT '';
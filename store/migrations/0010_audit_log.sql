@@ -0,0 +1,24 @@
+CREATE TABLE audit_log
+(
+    audit_id    INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    guild_id    TEXT    NOT NULL DEFAULT '',
+    user_id     TEXT    NOT NULL,
+    command     TEXT    NOT NULL,
+    options     TEXT    NOT NULL DEFAULT '{}',
+    status      TEXT    NOT NULL,
+    error       TEXT    NOT NULL DEFAULT '',
+    duration_ms INTEGER NOT NULL,
+    created_on  INTEGER NOT NULL
+);
+
+CREATE INDEX audit_log_guild_idx ON audit_log (guild_id, created_on);
+CREATE INDEX audit_log_user_idx ON audit_log (user_id, created_on);
+
+CREATE TABLE audit_targets
+(
+    audit_id INTEGER NOT NULL REFERENCES audit_log (audit_id) ON DELETE CASCADE,
+    steam_id INTEGER NOT NULL,
+    PRIMARY KEY (audit_id, steam_id)
+);
+
+CREATE INDEX audit_targets_steam_idx ON audit_targets (steam_id);
@@ -0,0 +1 @@
+ALTER TABLE ban_feeds ADD COLUMN seeded_on INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,10 @@
+ALTER TABLE ban_feeds DROP COLUMN seeded_on;
+
+CREATE TABLE ban_feed_players
+(
+    guild_id  TEXT    NOT NULL,
+    site_name TEXT    NOT NULL,
+    steam_id  INTEGER NOT NULL,
+    seeded_on INTEGER NOT NULL,
+    PRIMARY KEY (guild_id, site_name, steam_id)
+);
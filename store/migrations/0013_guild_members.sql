@@ -0,0 +1,7 @@
+CREATE TABLE guild_members
+(
+    guild_id   TEXT    NOT NULL,
+    user_id    TEXT    NOT NULL,
+    updated_on INTEGER NOT NULL,
+    PRIMARY KEY (guild_id, user_id)
+);
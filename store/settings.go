@@ -0,0 +1,202 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+const defaultTimezone = "UTC"
+
+// GuildSettings holds the per guild configuration.
+type GuildSettings struct {
+	GuildID        string
+	AlertChannelID string
+	ModRoleID      string
+	AdminRoleID    string
+	// DefaultSite is used to filter sourcebans when no site is explicitly selected.
+	DefaultSite      string
+	DisabledCommands []string
+	// Ephemeral controls if responses are only visible to the user who invoked the command.
+	Ephemeral bool
+	Timezone  string
+	// ScreeningEnabled controls if members are screened when joining the guild or linking their account.
+	ScreeningEnabled bool
+	// ScreeningChannelID is where screening results are posted, falling back to AlertChannelID when empty.
+	ScreeningChannelID string
+	QuarantineRoleID   string
+	// QuarantineThreshold is the risk score at which screened members are given the quarantine role. Zero
+	// disables quarantining.
+	QuarantineThreshold int
+	// MinAccountAge is the number of days old a steam account must be to not be flagged when screened.
+	MinAccountAge int
+	// RiskWeights overrides the default weight of risk factors, keyed by the factor name.
+	RiskWeights map[string]int
+	// Theme is the name of the embed theme, the default theme is used when empty or unknown.
+	Theme     string
+	CreatedOn time.Time
+	UpdatedOn time.Time
+}
+
+// CommandEnabled checks if the command has been disabled for the guild.
+func (s GuildSettings) CommandEnabled(name string) bool {
+	return !slices.Contains(s.DisabledCommands, name)
+}
+
+// SetCommandEnabled enables or disables the named command.
+func (s *GuildSettings) SetCommandEnabled(name string, enabled bool) {
+	s.DisabledCommands = slices.DeleteFunc(s.DisabledCommands, func(command string) bool {
+		return command == name
+	})
+
+	if !enabled {
+		s.DisabledCommands = append(s.DisabledCommands, name)
+		slices.Sort(s.DisabledCommands)
+	}
+}
+
+// Location returns the configured timezone, falling back to UTC if the timezone is invalid.
+func (s GuildSettings) Location() *time.Location {
+	location, errLocation := time.LoadLocation(s.Timezone)
+	if errLocation != nil {
+		return time.UTC
+	}
+
+	return location
+}
+
+// GuildSettings fetches the settings for a guild. If the guild has never saved any settings, the
+// defaults are returned instead.
+func (s *Store) GuildSettings(ctx context.Context, guildID string) (GuildSettings, error) {
+	return guildSettings(ctx, s.db, guildID)
+}
+
+// SaveGuildSettings creates or updates the settings for a guild.
+func (s *Store) SaveGuildSettings(ctx context.Context, settings *GuildSettings) error {
+	return saveGuildSettings(ctx, s.db, settings)
+}
+
+// UpdateGuildSettings applies update to the current settings of the guild and saves the result, within a
+// single transaction so concurrent updates of different settings are not lost. Errors returned by update
+// are returned as is, and nothing is saved.
+func (s *Store) UpdateGuildSettings(ctx context.Context, guildID string, update func(settings *GuildSettings) error) (GuildSettings, error) {
+	txn, errTx := s.db.BeginTx(ctx, nil)
+	if errTx != nil {
+		return GuildSettings{}, dbErr(errTx)
+	}
+
+	settings, errSettings := guildSettings(ctx, txn, guildID)
+	if errSettings != nil {
+		_ = txn.Rollback()
+
+		return settings, errSettings
+	}
+
+	if err := update(&settings); err != nil {
+		_ = txn.Rollback()
+
+		return settings, err
+	}
+
+	if err := saveGuildSettings(ctx, txn, &settings); err != nil {
+		_ = txn.Rollback()
+
+		return settings, err
+	}
+
+	return settings, dbErr(txn.Commit())
+}
+
+func guildSettings(ctx context.Context, db querier, guildID string) (GuildSettings, error) {
+	var (
+		settings  = GuildSettings{GuildID: guildID, Timezone: defaultTimezone}
+		disabled  string
+		weights   string
+		createdOn int64
+		updatedOn int64
+	)
+
+	errQuery := dbErr(db.QueryRowContext(ctx, `
+		SELECT alert_channel_id, mod_role_id, admin_role_id, default_site, disabled_commands, ephemeral, timezone,
+		       screening_enabled, screening_channel_id, quarantine_role_id, quarantine_threshold, min_account_age,
+		       risk_weights, theme, created_on, updated_on
+		FROM guild_settings
+		WHERE guild_id = ?`, guildID).
+		Scan(&settings.AlertChannelID, &settings.ModRoleID, &settings.AdminRoleID, &settings.DefaultSite, &disabled,
+			&settings.Ephemeral, &settings.Timezone, &settings.ScreeningEnabled, &settings.ScreeningChannelID,
+			&settings.QuarantineRoleID, &settings.QuarantineThreshold, &settings.MinAccountAge,
+			&weights, &settings.Theme, &createdOn, &updatedOn))
+	if errQuery != nil {
+		if errors.Is(errQuery, ErrNoResult) {
+			return settings, nil
+		}
+
+		return settings, errQuery
+	}
+
+	if disabled != "" {
+		settings.DisabledCommands = strings.Split(disabled, ",")
+	}
+
+	if err := json.Unmarshal([]byte(weights), &settings.RiskWeights); err != nil {
+		return settings, errors.Join(err, ErrQuery)
+	}
+
+	settings.CreatedOn = time.Unix(createdOn, 0)
+	settings.UpdatedOn = time.Unix(updatedOn, 0)
+
+	return settings, nil
+}
+
+func saveGuildSettings(ctx context.Context, db querier, settings *GuildSettings) error {
+	now := time.Now()
+	if settings.CreatedOn.IsZero() {
+		settings.CreatedOn = now
+	}
+	settings.UpdatedOn = now
+
+	if settings.Timezone == "" {
+		settings.Timezone = defaultTimezone
+	}
+
+	weights, errWeights := json.Marshal(settings.RiskWeights)
+	if errWeights != nil {
+		return errors.Join(errWeights, ErrQuery)
+	}
+
+	if settings.RiskWeights == nil {
+		weights = []byte("{}")
+	}
+
+	_, errExec := db.ExecContext(ctx, `
+		INSERT INTO guild_settings (guild_id, alert_channel_id, mod_role_id, admin_role_id, default_site,
+		                            disabled_commands, ephemeral, timezone, screening_enabled, screening_channel_id,
+		                            quarantine_role_id, quarantine_threshold, min_account_age, risk_weights, theme,
+		                            created_on, updated_on)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (guild_id) DO UPDATE SET
+			alert_channel_id = excluded.alert_channel_id,
+			mod_role_id = excluded.mod_role_id,
+			admin_role_id = excluded.admin_role_id,
+			default_site = excluded.default_site,
+			disabled_commands = excluded.disabled_commands,
+			ephemeral = excluded.ephemeral,
+			timezone = excluded.timezone,
+			screening_enabled = excluded.screening_enabled,
+			screening_channel_id = excluded.screening_channel_id,
+			quarantine_role_id = excluded.quarantine_role_id,
+			quarantine_threshold = excluded.quarantine_threshold,
+			min_account_age = excluded.min_account_age,
+			risk_weights = excluded.risk_weights,
+			theme = excluded.theme,
+			updated_on = excluded.updated_on`,
+		settings.GuildID, settings.AlertChannelID, settings.ModRoleID, settings.AdminRoleID, settings.DefaultSite,
+		strings.Join(settings.DisabledCommands, ","), settings.Ephemeral, settings.Timezone, settings.ScreeningEnabled,
+		settings.ScreeningChannelID, settings.QuarantineRoleID, settings.QuarantineThreshold, settings.MinAccountAge,
+		string(weights), settings.Theme, settings.CreatedOn.Unix(), settings.UpdatedOn.Unix())
+
+	return dbErr(errExec)
+}
@@ -0,0 +1,73 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/leighmacdonald/steamid/v4/steamid"
+)
+
+// SnapshotKind identifies what type of data a snapshot holds.
+type SnapshotKind string
+
+const (
+	SnapshotProfile SnapshotKind = "profile"
+	SnapshotWatch   SnapshotKind = "watch"
+)
+
+// Snapshot is a cached copy of some api data for a player, stored as JSON.
+type Snapshot struct {
+	SteamID   steamid.SteamID
+	Kind      SnapshotKind
+	Data      []byte
+	UpdatedOn time.Time
+}
+
+// Decode unmarshals the snapshot data into value.
+func (s Snapshot) Decode(value any) error {
+	if err := json.Unmarshal(s.Data, value); err != nil {
+		return errors.Join(err, ErrQuery)
+	}
+
+	return nil
+}
+
+// SaveSnapshot encodes value as JSON and stores it, replacing any existing snapshot of the same kind.
+func (s *Store) SaveSnapshot(ctx context.Context, steamID steamid.SteamID, kind SnapshotKind, value any) error {
+	data, errData := json.Marshal(value)
+	if errData != nil {
+		return errors.Join(errData, ErrQuery)
+	}
+
+	_, errExec := s.db.ExecContext(ctx, `
+		INSERT INTO snapshots (steam_id, kind, data, updated_on)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (steam_id, kind) DO UPDATE SET
+			data = excluded.data,
+			updated_on = excluded.updated_on`,
+		steamID.Int64(), kind, data, time.Now().Unix())
+
+	return dbErr(errExec)
+}
+
+// Snapshot fetches the most recent snapshot of the kind requested. ErrNoResult is returned if
+// none exists.
+func (s *Store) Snapshot(ctx context.Context, steamID steamid.SteamID, kind SnapshotKind) (Snapshot, error) {
+	var (
+		snapshot  = Snapshot{SteamID: steamID, Kind: kind}
+		updatedOn int64
+	)
+
+	if err := s.db.QueryRowContext(ctx, `
+		SELECT data, updated_on FROM snapshots WHERE steam_id = ? AND kind = ?`,
+		steamID.Int64(), kind).
+		Scan(&snapshot.Data, &updatedOn); err != nil {
+		return snapshot, dbErr(err)
+	}
+
+	snapshot.UpdatedOn = time.Unix(updatedOn, 0)
+
+	return snapshot, nil
+}
@@ -0,0 +1,159 @@
+// Package store implements the local persistent storage for the bot using an embedded sqlite database.
+package store
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+var (
+	ErrOpen      = errors.New("failed to open database")
+	ErrMigration = errors.New("failed to migrate database")
+	ErrQuery     = errors.New("failed to execute query")
+	ErrNoResult  = errors.New("no results")
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// querier is implemented by both *sql.DB and *sql.Tx, so queries can be shared by methods that need to run
+// them within a transaction.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Store provides access to everything the bot persists between restarts.
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens, creating if required, the sqlite database at the path provided and applies any
+// pending migrations. Any file path can be used, so tests can point it at a temporary file.
+func Open(ctx context.Context, dbPath string) (*Store, error) {
+	dsn := "file:" + dbPath + "?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)"
+
+	database, errDB := sql.Open("sqlite", dsn)
+	if errDB != nil {
+		return nil, errors.Join(errDB, ErrOpen)
+	}
+
+	// sqlite only supports a single writer, serialize access instead of relying on busy retries.
+	database.SetMaxOpenConns(1)
+
+	if errPing := database.PingContext(ctx); errPing != nil {
+		_ = database.Close()
+
+		return nil, errors.Join(errPing, ErrOpen)
+	}
+
+	store := &Store{db: database}
+
+	if errMigrate := store.migrate(ctx); errMigrate != nil {
+		_ = database.Close()
+
+		return nil, errMigrate
+	}
+
+	return store, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// migrate applies all the embedded migrations that have not been applied yet. Migrations are
+// applied in order of their numeric filename prefix, each within its own transaction.
+func (s *Store) migrate(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INTEGER NOT NULL PRIMARY KEY,
+			applied_on INTEGER NOT NULL
+		)`); err != nil {
+		return errors.Join(err, ErrMigration)
+	}
+
+	var current int
+	if err := s.db.QueryRowContext(ctx, `SELECT coalesce(max(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return errors.Join(err, ErrMigration)
+	}
+
+	entries, errEntries := fs.ReadDir(migrations, "migrations")
+	if errEntries != nil {
+		return errors.Join(errEntries, ErrMigration)
+	}
+
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+
+	for _, entry := range entries {
+		version, errVersion := strconv.Atoi(strings.SplitN(entry.Name(), "_", 2)[0])
+		if errVersion != nil {
+			return fmt.Errorf("%w: invalid migration name: %s", ErrMigration, entry.Name())
+		}
+
+		if version <= current {
+			continue
+		}
+
+		query, errRead := migrations.ReadFile(path.Join("migrations", entry.Name()))
+		if errRead != nil {
+			return errors.Join(errRead, ErrMigration)
+		}
+
+		if err := s.applyMigration(ctx, version, string(query)); err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrMigration, entry.Name(), err)
+		}
+
+		slog.Info("Applied database migration", slog.Int("version", version))
+	}
+
+	return nil
+}
+
+func (s *Store) applyMigration(ctx context.Context, version int, query string) error {
+	txn, errTx := s.db.BeginTx(ctx, nil)
+	if errTx != nil {
+		return errTx
+	}
+
+	if _, err := txn.ExecContext(ctx, query); err != nil {
+		_ = txn.Rollback()
+
+		return err
+	}
+
+	if _, err := txn.ExecContext(ctx, `INSERT INTO schema_migrations (version, applied_on) VALUES (?, ?)`,
+		version, time.Now().Unix()); err != nil {
+		_ = txn.Rollback()
+
+		return err
+	}
+
+	return txn.Commit()
+}
+
+func dbErr(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNoResult
+	}
+
+	return errors.Join(err, ErrQuery)
+}
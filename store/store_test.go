@@ -0,0 +1,510 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leighmacdonald/steamid/v4/steamid"
+)
+
+var (
+	testSteamID      = steamid.New(76561197970669109)
+	testOtherSteamID = steamid.New(76561197960287930)
+)
+
+// openTestStore opens a fresh database in a temporary file that is removed once the test completes.
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+
+	database, errOpen := Open(t.Context(), filepath.Join(t.TempDir(), "test.db"))
+	if errOpen != nil {
+		t.Fatalf("failed to open database: %v", errOpen)
+	}
+
+	t.Cleanup(func() {
+		if errClose := database.Close(); errClose != nil {
+			t.Errorf("failed to close database: %v", errClose)
+		}
+	})
+
+	return database
+}
+
+func TestMigrations(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	entries, errEntries := fs.ReadDir(migrations, "migrations")
+	if errEntries != nil {
+		t.Fatal(errEntries)
+	}
+
+	// Opening the same database again must not reapply any migration.
+	for range 2 {
+		database, errOpen := Open(t.Context(), dbPath)
+		if errOpen != nil {
+			t.Fatalf("failed to open database: %v", errOpen)
+		}
+
+		var applied int
+		if err := database.db.QueryRowContext(t.Context(), `SELECT count(*) FROM schema_migrations`).Scan(&applied); err != nil {
+			t.Fatal(err)
+		}
+
+		if applied != len(entries) {
+			t.Errorf("expected %d migrations, got %d", len(entries), applied)
+		}
+
+		if err := database.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGuildSettings(t *testing.T) {
+	database := openTestStore(t)
+	ctx := t.Context()
+
+	defaults, errDefaults := database.GuildSettings(ctx, "1")
+	if errDefaults != nil {
+		t.Fatal(errDefaults)
+	}
+
+	if defaults.GuildID != "1" || defaults.Timezone != defaultTimezone || !defaults.CreatedOn.IsZero() {
+		t.Errorf("unexpected default settings: %+v", defaults)
+	}
+
+	settings := GuildSettings{
+		GuildID:             "1",
+		AlertChannelID:      "2",
+		ModRoleID:           "3",
+		AdminRoleID:         "4",
+		DefaultSite:         "skial",
+		Ephemeral:           true,
+		Timezone:            "Europe/Berlin",
+		ScreeningEnabled:    true,
+		ScreeningChannelID:  "5",
+		QuarantineRoleID:    "6",
+		QuarantineThreshold: 50,
+		MinAccountAge:       30,
+		RiskWeights:         map[string]int{"vac_ban": 10},
+		Theme:               "dark",
+	}
+	settings.SetCommandEnabled("stats", false)
+	settings.SetCommandEnabled("alts", false)
+
+	if err := database.SaveGuildSettings(ctx, &settings); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, errLoaded := database.GuildSettings(ctx, "1")
+	if errLoaded != nil {
+		t.Fatal(errLoaded)
+	}
+
+	if !slices.Equal(loaded.DisabledCommands, []string{"alts", "stats"}) {
+		t.Errorf("unexpected disabled commands: %v", loaded.DisabledCommands)
+	}
+
+	if loaded.CommandEnabled("stats") || !loaded.CommandEnabled("check") {
+		t.Error("expected only the disabled commands to be disabled")
+	}
+
+	if loaded.RiskWeights["vac_ban"] != 10 {
+		t.Errorf("unexpected risk weights: %v", loaded.RiskWeights)
+	}
+
+	if loaded.Location().String() != "Europe/Berlin" {
+		t.Errorf("unexpected location: %s", loaded.Location())
+	}
+
+	// Compare the remaining fields, the times are only stored to the second.
+	loaded.DisabledCommands, settings.DisabledCommands = nil, nil
+	loaded.RiskWeights, settings.RiskWeights = nil, nil
+	loaded.CreatedOn, settings.CreatedOn = time.Time{}, time.Time{}
+	loaded.UpdatedOn, settings.UpdatedOn = time.Time{}, time.Time{}
+
+	if !reflect.DeepEqual(loaded, settings) {
+		t.Errorf("expected %+v, got %+v", settings, loaded)
+	}
+
+	other, errOther := database.GuildSettings(ctx, "2")
+	if errOther != nil {
+		t.Fatal(errOther)
+	}
+
+	if other.AlertChannelID != "" {
+		t.Error("settings leaked into another guild")
+	}
+}
+
+func TestUpdateGuildSettings(t *testing.T) {
+	database := openTestStore(t)
+	ctx := t.Context()
+
+	var wg sync.WaitGroup
+
+	for i := range 10 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, errUpdate := database.UpdateGuildSettings(ctx, "1", func(settings *GuildSettings) error {
+				settings.SetCommandEnabled(fmt.Sprintf("command%d", i), false)
+
+				return nil
+			})
+			if errUpdate != nil {
+				t.Error(errUpdate)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	loaded, errLoaded := database.GuildSettings(ctx, "1")
+	if errLoaded != nil {
+		t.Fatal(errLoaded)
+	}
+
+	if len(loaded.DisabledCommands) != 10 {
+		t.Errorf("expected every update to be kept, got: %v", loaded.DisabledCommands)
+	}
+
+	errAbort := errors.New("abort")
+
+	_, errUpdate := database.UpdateGuildSettings(ctx, "1", func(settings *GuildSettings) error {
+		settings.AlertChannelID = "2"
+
+		return errAbort
+	})
+	if !errors.Is(errUpdate, errAbort) {
+		t.Fatalf("expected the update error, got: %v", errUpdate)
+	}
+
+	aborted, errAborted := database.GuildSettings(ctx, "1")
+	if errAborted != nil {
+		t.Fatal(errAborted)
+	}
+
+	if aborted.AlertChannelID != "" {
+		t.Error("expected a failed update not to be saved")
+	}
+}
+
+func TestWatchlist(t *testing.T) {
+	database := openTestStore(t)
+	ctx := t.Context()
+
+	for _, watch := range []Watch{
+		{GuildID: "1", SteamID: testSteamID, Note: "first", AddedBy: "10", CreatedOn: time.Unix(1000, 0)},
+		{GuildID: "1", SteamID: testOtherSteamID, Note: "second", AddedBy: "10", CreatedOn: time.Unix(2000, 0)},
+		{GuildID: "2", SteamID: testSteamID, Note: "other guild", AddedBy: "20", CreatedOn: time.Unix(3000, 0)},
+	} {
+		if err := database.AddWatch(ctx, &watch); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Adding an existing watch only updates the note.
+	if err := database.AddWatch(ctx, &Watch{GuildID: "1", SteamID: testSteamID, Note: "updated", AddedBy: "11"}); err != nil {
+		t.Fatal(err)
+	}
+
+	watches, errWatches := database.Watches(ctx, "1")
+	if errWatches != nil {
+		t.Fatal(errWatches)
+	}
+
+	if len(watches) != 2 {
+		t.Fatalf("expected 2 watches, got %d", len(watches))
+	}
+
+	if watches[0].SteamID != testSteamID || watches[0].Note != "updated" || watches[0].AddedBy != "10" ||
+		!watches[0].CreatedOn.Equal(time.Unix(1000, 0)) {
+		t.Errorf("unexpected watch: %+v", watches[0])
+	}
+
+	all, errAll := database.Watches(ctx, "")
+	if errAll != nil {
+		t.Fatal(errAll)
+	}
+
+	if len(all) != 3 {
+		t.Errorf("expected 3 watches across all guilds, got %d", len(all))
+	}
+
+	if err := database.RemoveWatch(ctx, "1", testSteamID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := database.RemoveWatch(ctx, "1", testSteamID); !errors.Is(err, ErrNoResult) {
+		t.Errorf("expected ErrNoResult removing a missing watch, got %v", err)
+	}
+
+	remaining, errRemaining := database.Watches(ctx, "1")
+	if errRemaining != nil {
+		t.Fatal(errRemaining)
+	}
+
+	if len(remaining) != 1 || remaining[0].SteamID != testOtherSteamID {
+		t.Errorf("unexpected watches after removal: %+v", remaining)
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	database := openTestStore(t)
+	ctx := t.Context()
+
+	entries := []AuditEntry{
+		{
+			GuildID: "1", UserID: "10", Command: "check", Options: map[string]string{"steamid": "a"},
+			SteamIDs: []steamid.SteamID{testSteamID}, Status: "ok", Duration: time.Millisecond * 150,
+			CreatedOn: time.Unix(1000, 0),
+		},
+		{
+			GuildID: "1", UserID: "11", Command: "bulkcheck", Options: map[string]string{},
+			SteamIDs: []steamid.SteamID{testSteamID, testOtherSteamID}, Status: "error", Error: "failed",
+			CreatedOn: time.Unix(2000, 0),
+		},
+		{GuildID: "1", UserID: "10", Command: "stats", Status: "ok", CreatedOn: time.Unix(3000, 0)},
+		{GuildID: "2", UserID: "10", Command: "check", SteamIDs: []steamid.SteamID{testSteamID}, Status: "ok", CreatedOn: time.Unix(4000, 0)},
+	}
+
+	for i := range entries {
+		if err := database.AddAuditEntry(ctx, &entries[i]); err != nil {
+			t.Fatal(err)
+		}
+
+		if entries[i].AuditID == 0 {
+			t.Error("expected the audit id to be set")
+		}
+	}
+
+	tests := []struct {
+		name     string
+		filter   AuditFilter
+		expected []string
+	}{
+		{"guild", AuditFilter{GuildID: "1"}, []string{"stats", "bulkcheck", "check"}},
+		{"user", AuditFilter{GuildID: "1", UserID: "10"}, []string{"stats", "check"}},
+		{"target", AuditFilter{GuildID: "1", SteamID: testOtherSteamID}, []string{"bulkcheck"}},
+		{"range", AuditFilter{GuildID: "1", After: time.Unix(1500, 0), Before: time.Unix(3000, 0)}, []string{"bulkcheck"}},
+		{"limit", AuditFilter{Limit: 2}, []string{"check", "stats"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.filter.Limit == 0 {
+				test.filter.Limit = 100
+			}
+
+			found, errFound := database.AuditEntries(ctx, test.filter)
+			if errFound != nil {
+				t.Fatal(errFound)
+			}
+
+			commands := make([]string, len(found))
+			for i, entry := range found {
+				commands[i] = entry.Command
+			}
+
+			if !slices.Equal(commands, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, commands)
+			}
+		})
+	}
+
+	found, errFound := database.AuditEntries(ctx, AuditFilter{GuildID: "1", SteamID: testOtherSteamID, Limit: 1})
+	if errFound != nil || len(found) != 1 {
+		t.Fatalf("failed to load entry: %v", errFound)
+	}
+
+	entry := found[0]
+	if entry.Error != "failed" || entry.Status != "error" || len(entry.SteamIDs) != 2 ||
+		!entry.CreatedOn.Equal(time.Unix(2000, 0)) {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+
+	first, errFirst := database.AuditEntries(ctx, AuditFilter{UserID: "10", Before: time.Unix(1500, 0), Limit: 1})
+	if errFirst != nil || len(first) != 1 {
+		t.Fatalf("failed to load entry: %v", errFirst)
+	}
+
+	if first[0].Options["steamid"] != "a" || first[0].Duration != time.Millisecond*150 {
+		t.Errorf("unexpected entry: %+v", first[0])
+	}
+}
+
+func TestSnapshots(t *testing.T) {
+	database := openTestStore(t)
+	ctx := t.Context()
+
+	type data struct {
+		Name string `json:"name"`
+	}
+
+	if _, err := database.Snapshot(ctx, testSteamID, SnapshotProfile); !errors.Is(err, ErrNoResult) {
+		t.Errorf("expected ErrNoResult for a missing snapshot, got %v", err)
+	}
+
+	if err := database.SaveSnapshot(ctx, testSteamID, SnapshotProfile, data{Name: "first"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := database.SaveSnapshot(ctx, testSteamID, SnapshotProfile, data{Name: "second"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := database.SaveSnapshot(ctx, testSteamID, SnapshotWatch, data{Name: "watch"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for kind, expected := range map[SnapshotKind]string{SnapshotProfile: "second", SnapshotWatch: "watch"} {
+		snapshot, errSnapshot := database.Snapshot(ctx, testSteamID, kind)
+		if errSnapshot != nil {
+			t.Fatal(errSnapshot)
+		}
+
+		var decoded data
+		if err := snapshot.Decode(&decoded); err != nil {
+			t.Fatal(err)
+		}
+
+		if decoded.Name != expected {
+			t.Errorf("expected %s snapshot %q, got %q", kind, expected, decoded.Name)
+		}
+
+		if snapshot.UpdatedOn.IsZero() {
+			t.Error("expected updated on to be set")
+		}
+	}
+
+	if _, err := database.Snapshot(context.Background(), testOtherSteamID, SnapshotProfile); !errors.Is(err, ErrNoResult) {
+		t.Errorf("expected ErrNoResult for another player, got %v", err)
+	}
+}
+
+func TestBanFeeds(t *testing.T) {
+	database := openTestStore(t)
+	ctx := t.Context()
+
+	feed := BanFeed{GuildID: "1", SiteName: "skial", ChannelID: "2"}
+	if err := database.SaveBanFeed(ctx, &feed); err != nil {
+		t.Fatal(err)
+	}
+
+	feeds, errFeeds := database.BanFeeds(ctx, "1")
+	if errFeeds != nil || len(feeds) != 1 {
+		t.Fatalf("failed to load feeds: %v", errFeeds)
+	}
+
+	if seeded, err := database.BanFeedSeeded(ctx, feeds[0], testSteamID); err != nil || seeded {
+		t.Fatalf("expected the player to not be seeded: %v", err)
+	}
+
+	if err := database.SetBanFeedSeeded(ctx, feeds[0], testSteamID); err != nil {
+		t.Fatal(err)
+	}
+
+	// Changing the channel must not reset the seeding.
+	feed.ChannelID = "3"
+	if err := database.SaveBanFeed(ctx, &feed); err != nil {
+		t.Fatal(err)
+	}
+
+	feeds, errFeeds = database.BanFeeds(ctx, "")
+	if errFeeds != nil || len(feeds) != 1 || feeds[0].ChannelID != "3" {
+		t.Fatalf("unexpected feeds: %+v %v", feeds, errFeeds)
+	}
+
+	if seeded, err := database.BanFeedSeeded(ctx, feeds[0], testSteamID); err != nil || !seeded {
+		t.Fatalf("expected the player to be seeded: %v", err)
+	}
+
+	if seeded, err := database.BanFeedSeeded(ctx, feeds[0], testOtherSteamID); err != nil || seeded {
+		t.Fatalf("expected another player to not be seeded: %v", err)
+	}
+
+	ban := AnnouncedBan{GuildID: "1", SiteName: "skial", SteamID: testSteamID, BanCreated: time.Unix(1000, 0)}
+
+	if announced, err := database.BanAnnounced(ctx, ban); err != nil || announced {
+		t.Fatalf("expected the ban to not be announced: %v", err)
+	}
+
+	if err := database.AddAnnouncedBan(ctx, &ban); err != nil {
+		t.Fatal(err)
+	}
+
+	if announced, err := database.BanAnnounced(ctx, ban); err != nil || !announced {
+		t.Fatalf("expected the ban to be announced: %v", err)
+	}
+
+	// Subscribing again must seed the players again.
+	if err := database.RemoveBanFeed(ctx, "1", "skial"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := database.RemoveBanFeed(ctx, "1", "skial"); !errors.Is(err, ErrNoResult) {
+		t.Errorf("expected ErrNoResult when not subscribed, got %v", err)
+	}
+
+	if err := database.SaveBanFeed(ctx, &feed); err != nil {
+		t.Fatal(err)
+	}
+
+	if seeded, err := database.BanFeedSeeded(ctx, feed, testSteamID); err != nil || seeded {
+		t.Fatalf("expected the player to not be seeded after subscribing again: %v", err)
+	}
+}
+
+func TestGuildAccountLinks(t *testing.T) {
+	database := openTestStore(t)
+	ctx := t.Context()
+
+	for _, link := range []AccountLink{
+		{UserID: "10", SteamID: testSteamID, LinkedBy: "10"},
+		{UserID: "11", SteamID: testOtherSteamID, LinkedBy: "11"},
+	} {
+		if err := database.SaveAccountLink(ctx, &link); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Recording a member twice must not duplicate them.
+	for _, userID := range []string{"10", "10", "12"} {
+		if err := database.AddGuildMember(ctx, "1", userID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := database.AddGuildMember(ctx, "2", "11"); err != nil {
+		t.Fatal(err)
+	}
+
+	links, errLinks := database.GuildAccountLinks(ctx, "1")
+	if errLinks != nil {
+		t.Fatal(errLinks)
+	}
+
+	if len(links) != 1 || links[0].UserID != "10" || links[0].SteamID != testSteamID {
+		t.Fatalf("expected only the linked member of the guild, got %+v", links)
+	}
+
+	if err := database.RemoveGuildMember(ctx, "1", "10"); err != nil {
+		t.Fatal(err)
+	}
+
+	links, errLinks = database.GuildAccountLinks(ctx, "1")
+	if errLinks != nil || len(links) != 0 {
+		t.Errorf("expected no links after the member left, got %+v %v", links, errLinks)
+	}
+}
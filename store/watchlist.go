@@ -0,0 +1,84 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/leighmacdonald/steamid/v4/steamid"
+)
+
+// Watch is a player being tracked by a guild.
+type Watch struct {
+	GuildID   string
+	SteamID   steamid.SteamID
+	Note      string
+	AddedBy   string
+	CreatedOn time.Time
+}
+
+// AddWatch adds a player to the guilds watchlist, updating the note if they are already being watched.
+func (s *Store) AddWatch(ctx context.Context, watch *Watch) error {
+	if watch.CreatedOn.IsZero() {
+		watch.CreatedOn = time.Now()
+	}
+
+	_, errExec := s.db.ExecContext(ctx, `
+		INSERT INTO watchlist (guild_id, steam_id, note, added_by, created_on)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (guild_id, steam_id) DO UPDATE SET note = excluded.note`,
+		watch.GuildID, watch.SteamID.Int64(), watch.Note, watch.AddedBy, watch.CreatedOn.Unix())
+
+	return dbErr(errExec)
+}
+
+// RemoveWatch removes a player from the guilds watchlist. ErrNoResult is returned if the player was
+// not being watched.
+func (s *Store) RemoveWatch(ctx context.Context, guildID string, steamID steamid.SteamID) error {
+	result, errExec := s.db.ExecContext(ctx, `DELETE FROM watchlist WHERE guild_id = ? AND steam_id = ?`,
+		guildID, steamID.Int64())
+	if errExec != nil {
+		return dbErr(errExec)
+	}
+
+	affected, errAffected := result.RowsAffected()
+	if errAffected != nil {
+		return dbErr(errAffected)
+	}
+
+	if affected == 0 {
+		return ErrNoResult
+	}
+
+	return nil
+}
+
+// Watches returns the watchlist for a guild. If guildID is empty, the watchlists for all guilds are returned.
+func (s *Store) Watches(ctx context.Context, guildID string) ([]Watch, error) {
+	rows, errRows := s.db.QueryContext(ctx, `
+		SELECT guild_id, steam_id, note, added_by, created_on
+		FROM watchlist
+		WHERE ? = '' OR guild_id = ?
+		ORDER BY created_on`, guildID, guildID)
+	if errRows != nil {
+		return nil, dbErr(errRows)
+	}
+	defer rows.Close()
+
+	var watches []Watch
+
+	for rows.Next() {
+		var (
+			watch     Watch
+			createdOn int64
+		)
+
+		if err := rows.Scan(&watch.GuildID, &watch.SteamID, &watch.Note, &watch.AddedBy, &createdOn); err != nil {
+			return nil, dbErr(err)
+		}
+
+		watch.CreatedOn = time.Unix(createdOn, 0)
+		watches = append(watches, watch)
+	}
+
+	return watches, dbErr(rows.Err())
+}
@@ -0,0 +1,57 @@
+package tfapi
+
+import (
+	"sync"
+	"time"
+
+	"github.com/leighmacdonald/steamid/v4/steamid"
+)
+
+// DefaultProfileCacheTTL is how long a fetched profile is reused before it is fetched again, unless
+// configured otherwise.
+const DefaultProfileCacheTTL = time.Minute * 5
+
+// profileCache keeps recently fetched profiles in memory. Commands such as /check and /friends are often
+// used on the same players in quick succession, and the background watchers poll the same players again.
+type profileCache struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	profiles map[steamid.SteamID]Profile
+}
+
+func newProfileCache(ttl time.Duration) *profileCache {
+	return &profileCache{ttl: ttl, profiles: map[steamid.SteamID]Profile{}}
+}
+
+func (c *profileCache) get(steamID steamid.SteamID) (Profile, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	profile, found := c.profiles[steamID]
+	if !found || c.ttl < 0 || time.Since(profile.FetchedOn) > c.ttl {
+		return Profile{}, false
+	}
+
+	return profile, true
+}
+
+// set adds the profiles to the cache, evicting any expired entries. Nothing is cached when the cache is
+// disabled.
+func (c *profileCache) set(profiles ...Profile) {
+	if c.ttl < 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for steamID, profile := range c.profiles {
+		if time.Since(profile.FetchedOn) > c.ttl {
+			delete(c.profiles, steamID)
+		}
+	}
+
+	for _, profile := range profiles {
+		c.profiles[profile.SteamID] = profile
+	}
+}
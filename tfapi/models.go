@@ -0,0 +1,565 @@
+package tfapi
+
+import (
+	"time"
+
+	"github.com/leighmacdonald/steamid/v4/steamid"
+)
+
+// Visibility represents the community visibility state of a steam profile.
+type Visibility int
+
+const (
+	VisibilityPrivate     Visibility = 1
+	VisibilityFriendsOnly Visibility = 2
+	VisibilityPublic      Visibility = 3
+)
+
+func (v Visibility) String() string {
+	switch v {
+	case VisibilityPrivate:
+		return "Private"
+	case VisibilityFriendsOnly:
+		return "Friends Only"
+	case VisibilityPublic:
+		return "Public"
+	default:
+		return "Unknown"
+	}
+}
+
+// EconomyBan represents the trade ban state of a steam account.
+type EconomyBan string
+
+const (
+	EconomyBanNone      EconomyBan = "none"
+	EconomyBanProbation EconomyBan = "probation"
+	EconomyBanBanned    EconomyBan = "banned"
+)
+
+// Profile is a high level summary of a player combining steam, sourcebans and league data.
+type Profile struct {
+	SteamID           steamid.SteamID
+	PersonaName       string
+	RealName          string
+	AvatarHash        string
+	TimeCreated       time.Time
+	Visibility        Visibility
+	ProfileConfigured bool
+	CommunityBanned   bool
+	EconomyBan        EconomyBan
+	VACBans           int
+	GameBans          int
+	DaysSinceLastBan  int
+	LogsCount         int
+	Bans              []SourceBan
+	CompetitiveTeams  []LeagueTeam
+	Friends           []Friend
+	// FetchedOn is when the profile was fetched from the api, profiles may be served from a cache.
+	FetchedOn time.Time
+}
+
+// SourceBan is a ban from a 3rd party site such as a sourcebans instance or a league.
+type SourceBan struct {
+	SteamID     steamid.SteamID
+	SiteName    string
+	Name        string
+	Reason      string
+	CreatedOn   time.Time
+	ExpiresOn   time.Time
+	Permanent   bool
+	Unbanned    bool
+	UnbanReason string
+}
+
+// Active checks if the ban is still in effect at the current time.
+func (b SourceBan) Active() bool {
+	if b.Unbanned {
+		return false
+	}
+
+	return b.Permanent || b.ExpiresOn.IsZero() || b.ExpiresOn.After(time.Now())
+}
+
+// LeagueTeam is a single team membership from a competitive league.
+type LeagueTeam struct {
+	League       string
+	LeagueID     int64
+	TeamName     string
+	Tag          string
+	Alias        string
+	DivisionName string
+	SeasonName   string
+	Format       string
+	Region       string
+	Type         string
+	Rank         int
+	Leader       bool
+	JoinedTeam   time.Time
+	LeftTeam     time.Time
+}
+
+// Friend is a steam friend list relationship. RemovedOn is zero while the relationship still exists.
+type Friend struct {
+	SteamID      steamid.SteamID
+	Relationship string
+	FriendSince  time.Time
+	RemovedOn    time.Time
+}
+
+// BotDetectorMatch is an entry for a player on a tf2 bot detector list.
+type BotDetectorMatch struct {
+	SteamID      steamid.SteamID
+	ListName     string
+	Attributes   []string
+	Proof        []string
+	LastSeenName string
+	LastSeen     time.Time
+}
+
+// SteamBanState is the current steam ban status of a player.
+type SteamBanState struct {
+	SteamID          steamid.SteamID
+	CommunityBanned  bool
+	VACBanned        bool
+	VACBans          int
+	GameBans         int
+	DaysSinceLastBan int
+	EconomyBan       EconomyBan
+}
+
+// Banned checks if the player has any kind of steam ban.
+func (s SteamBanState) Banned() bool {
+	return s.CommunityBanned || s.VACBans > 0 || s.GameBans > 0 || s.EconomyBan == EconomyBanBanned
+}
+
+// PlayerSummary is the current public steam profile of a player.
+type PlayerSummary struct {
+	SteamID     steamid.SteamID
+	PersonaName string
+	RealName    string
+	AvatarHash  string
+	ProfileURL  string
+	Visibility  Visibility
+	TimeCreated time.Time
+}
+
+// Reputation is the steamrep.com reputation of a player.
+type Reputation struct {
+	SteamID steamid.SteamID
+	Banned  bool
+	// Tags lists the reputation sources that have tagged the player, eg: scammer.
+	Tags []string
+}
+
+// ChatMessage is a chat message sent by a player during a logs.tf match.
+type ChatMessage struct {
+	SteamID steamid.SteamID
+	LogID   int64
+	Name    string
+	Message string
+	// CreatedOn is the date of the match, not the message specifically.
+	CreatedOn time.Time
+}
+
+// Site is a 3rd party ban source tracked by the api.
+type Site struct {
+	Name  string
+	Title string
+	Type  string
+	URL   string
+}
+
+// Stats contains the overall counts of data indexed by the api.
+type Stats struct {
+	Players            int
+	Names              int
+	Avatars            int
+	Friends            int
+	Maps               int
+	Sources            int
+	BanTotal           int
+	BanCounts          map[string]int
+	VACBans            int
+	GameBans           int
+	CommunityBans      int
+	BotDetectorLists   int
+	BotDetectorEntries int
+	Leagues            int
+	LeagueTeams        int
+	LogsTFLogs         int
+	LogsTFPlayers      int
+	LogsTFMessages     int
+}
+
+// Match is a short summary of a logs.tf match.
+type Match struct {
+	LogID     int64
+	Title     string
+	Map       string
+	CreatedOn time.Time
+	ScoreRed  int
+	ScoreBlu  int
+}
+
+// MatchDetail is the full logs.tf record of a single match.
+type MatchDetail struct {
+	Match
+	Players []MatchPlayer
+	// Rounds is empty for old logs that did not record rounds.
+	Rounds []MatchRound
+}
+
+// MatchRound is the team totals of a single round within a match.
+type MatchRound struct {
+	Round     int
+	Length    time.Duration
+	KillsRed  int
+	KillsBlu  int
+	DamageRed int
+	DamageBlu int
+}
+
+// MatchPlayer is the overall stats of a single player within a match.
+type MatchPlayer struct {
+	SteamID steamid.SteamID
+	Name    string
+	Team    string
+	Kills   int
+	Deaths  int
+	Assists int
+	Damage  int
+	DPM     int
+	Classes []MatchClass
+}
+
+// MatchClass is the stats of a player while playing a single class within a match.
+type MatchClass struct {
+	Class  string
+	Played time.Duration
+	Kills  int
+	Deaths int
+	Damage int
+}
+
+// LeagueTeamMember is a single player on a league team roster.
+type LeagueTeamMember struct {
+	SteamID steamid.SteamID
+	Name    string
+	Leader  bool
+	Joined  time.Time
+	Left    time.Time
+}
+
+// LogSummary contains the aggregated logs.tf stats for a player across all of their logs.
+type LogSummary struct {
+	Logs            int
+	Kills           int
+	Deaths          int
+	Assists         int
+	Damage          int
+	DamageTaken     int
+	Airshots        int
+	Headshots       int
+	Backstabs       int
+	Caps            int
+	HealthPacks     int
+	HealingTaken    int
+	KillsAvg        float64
+	DeathsAvg       float64
+	AssistsAvg      float64
+	DamageAvg       float64
+	DamageTakenAvg  float64
+	HealingTakenAvg float64
+	DPMAvg          float64
+	DTMAvg          float64
+	KDAvg           float64
+	KADAvg          float64
+}
+
+func newProfile(profile MetaProfile) Profile {
+	bans := make([]SourceBan, len(profile.Bans))
+	for i, ban := range profile.Bans {
+		bans[i] = newSourceBan(ban)
+	}
+
+	teams := make([]LeagueTeam, len(profile.CompetitiveTeams))
+	for i, team := range profile.CompetitiveTeams {
+		teams[i] = newLeagueTeam(team)
+	}
+
+	friends := make([]Friend, len(profile.Friends))
+	for i, friend := range profile.Friends {
+		friends[i] = newFriend(friend)
+	}
+
+	return Profile{
+		SteamID:           steamid.New(profile.SteamId),
+		PersonaName:       profile.PersonaName,
+		RealName:          profile.RealName,
+		AvatarHash:        profile.AvatarHash,
+		TimeCreated:       time.Unix(profile.TimeCreated, 0),
+		Visibility:        Visibility(profile.CommunityVisibilityState),
+		ProfileConfigured: profile.ProfileState == MetaProfileProfileStateN1,
+		CommunityBanned:   profile.CommunityBanned,
+		EconomyBan:        EconomyBan(profile.EconomyBan),
+		VACBans:           int(profile.NumberOfVacBans),
+		GameBans:          int(profile.NumberOfGameBans),
+		DaysSinceLastBan:  int(profile.DaysSinceLastBan),
+		LogsCount:         int(profile.LogsCount),
+		Bans:              bans,
+		CompetitiveTeams:  teams,
+		Friends:           friends,
+		FetchedOn:         time.Now(),
+	}
+}
+
+func newSourceBan(ban Ban) SourceBan {
+	return SourceBan{
+		SteamID:     steamid.New(ban.SteamId),
+		SiteName:    ban.SiteName,
+		Name:        ban.Name,
+		Reason:      ban.Reason,
+		CreatedOn:   ban.CreatedOn,
+		ExpiresOn:   ban.ExpiresOn,
+		Permanent:   ban.Permanent,
+		Unbanned:    ban.Unbanned,
+		UnbanReason: ban.UnbanReason,
+	}
+}
+
+func newLeagueTeam(team LeaguePlayerTeamHistory) LeagueTeam {
+	return LeagueTeam{
+		League:       team.League,
+		LeagueID:     team.LeagueId,
+		TeamName:     team.TeamName,
+		Tag:          team.Tag,
+		Alias:        team.Alias,
+		DivisionName: team.DivisionName,
+		SeasonName:   team.SeasonName,
+		Format:       team.Format,
+		Region:       team.Region,
+		Type:         team.Type,
+		Rank:         int(team.Rank),
+		Leader:       team.Leader,
+		JoinedTeam:   team.JoinedTeam,
+		LeftTeam:     team.LeftTeam,
+	}
+}
+
+func newFriend(friend SteamFriend) Friend {
+	return Friend{
+		SteamID:      steamid.New(friend.SteamId),
+		Relationship: friend.Relationship,
+		FriendSince:  friend.FriendSince,
+		RemovedOn:    friend.RemovedOn,
+	}
+}
+
+func newBotDetectorMatch(steamID steamid.SteamID, result BDSearchResult) BotDetectorMatch {
+	match := BotDetectorMatch{
+		SteamID:      steamID,
+		ListName:     result.ListName,
+		Attributes:   result.Match.Attributes,
+		Proof:        result.Match.Proof,
+		LastSeenName: result.Match.LastSeen.PlayerName,
+	}
+
+	if result.Match.LastSeen.Time > 0 {
+		match.LastSeen = time.Unix(result.Match.LastSeen.Time, 0)
+	}
+
+	return match
+}
+
+func newSteamBanState(ban SteamBan) SteamBanState {
+	return SteamBanState{
+		SteamID:          steamid.New(ban.SteamId),
+		CommunityBanned:  ban.CommunityBanned,
+		VACBanned:        ban.VacBanned,
+		VACBans:          int(ban.NumberOfVacBans),
+		GameBans:         int(ban.NumberOfGameBans),
+		DaysSinceLastBan: int(ban.DaysSinceLastBan),
+		EconomyBan:       EconomyBan(ban.EconomyBan),
+	}
+}
+
+func newPlayerSummary(summary PlayerSummaryResponse) PlayerSummary {
+	return PlayerSummary{
+		SteamID:     steamid.New(summary.SteamId),
+		PersonaName: summary.PersonaName,
+		RealName:    summary.RealName,
+		AvatarHash:  summary.AvatarHash,
+		ProfileURL:  summary.ProfileUrl,
+		Visibility:  Visibility(summary.VisibilityState),
+		TimeCreated: time.Unix(summary.TimeCreated, 0),
+	}
+}
+
+func newReputation(entry SteamRepEntry) Reputation {
+	return Reputation{
+		SteamID: steamid.New(entry.SteamId),
+		Banned:  entry.Banned,
+		Tags:    entry.Reputations,
+	}
+}
+
+func newChatMessage(chat LogsTFChat) ChatMessage {
+	return ChatMessage{
+		SteamID:   steamid.New(chat.SteamId),
+		LogID:     chat.LogId,
+		Name:      chat.Name,
+		Message:   chat.Message,
+		CreatedOn: chat.CreatedOn,
+	}
+}
+
+func newSite(site SiteInfo) Site {
+	return Site{
+		Name:  site.Name,
+		Title: site.Title,
+		Type:  site.Type,
+		URL:   site.Url,
+	}
+}
+
+func newStats(stats StatsOverall) Stats {
+	banCounts := make(map[string]int, len(stats.BanCounts))
+	for site, count := range stats.BanCounts {
+		banCounts[site] = int(count)
+	}
+
+	return Stats{
+		Players:            int(stats.PlayersCount),
+		Names:              int(stats.NameCount),
+		Avatars:            int(stats.AvatarCount),
+		Friends:            int(stats.FriendCount),
+		Maps:               int(stats.MapsCount),
+		Sources:            int(stats.SourceCount),
+		BanTotal:           int(stats.BanTotalCount),
+		BanCounts:          banCounts,
+		VACBans:            int(stats.VacCount),
+		GameBans:           int(stats.GameBanCount),
+		CommunityBans:      int(stats.CommunityBanCount),
+		BotDetectorLists:   int(stats.BdListCount),
+		BotDetectorEntries: int(stats.BdListEntriesCount),
+		Leagues:            int(stats.LeaguesCount),
+		LeagueTeams:        int(stats.LeaguesTeamCount),
+		LogsTFLogs:         int(stats.LogsTfCount),
+		LogsTFPlayers:      int(stats.LogsTfPlayerCount),
+		LogsTFMessages:     int(stats.LogsTfChatCount),
+	}
+}
+
+func newMatch(match LogsTFMatchInfo) Match {
+	return Match{
+		LogID:     match.LogId,
+		Title:     match.Title,
+		Map:       match.Map,
+		CreatedOn: match.CreatedOn,
+		ScoreRed:  int(match.ScoreRed),
+		ScoreBlu:  int(match.ScoreBlu),
+	}
+}
+
+func newMatchDetail(match LogsTFMatch) MatchDetail {
+	detail := MatchDetail{
+		Match: Match{
+			LogID:     match.LogId,
+			Title:     match.Title,
+			Map:       match.Map,
+			CreatedOn: match.CreatedOn,
+			ScoreRed:  int(match.ScoreRed),
+			ScoreBlu:  int(match.ScoreBlu),
+		},
+		Players: make([]MatchPlayer, len(match.Players)),
+		Rounds:  make([]MatchRound, len(match.Rounds)),
+	}
+
+	for i, player := range match.Players {
+		classes := make([]MatchClass, len(player.Classes))
+		for j, class := range player.Classes {
+			classes[j] = MatchClass{
+				Class:  class.Class,
+				Played: newDuration(class.Played),
+				Kills:  int(class.Kills),
+				Deaths: int(class.Deaths),
+				Damage: int(class.Damage),
+			}
+		}
+
+		detail.Players[i] = MatchPlayer{
+			SteamID: steamid.New(player.SteamId),
+			Name:    player.Name,
+			Team:    player.Team,
+			Kills:   int(player.Kills),
+			Deaths:  int(player.Deaths),
+			Assists: int(player.Assists),
+			Damage:  int(player.Damage),
+			DPM:     int(player.Dpm),
+			Classes: classes,
+		}
+	}
+
+	for i, round := range match.Rounds {
+		detail.Rounds[i] = MatchRound{
+			Round:     int(round.Round),
+			Length:    newDuration(round.Length),
+			KillsRed:  int(round.KillsRed),
+			KillsBlu:  int(round.KillsBlu),
+			DamageRed: int(round.DamageRed),
+			DamageBlu: int(round.DamageBlu),
+		}
+	}
+
+	return detail
+}
+
+// newDuration converts the api duration objects. The schema doesn't describe their fields, they are
+// encoded from a Go time.Duration so the numeric value is read as nanoseconds.
+func newDuration(value Duration) time.Duration {
+	for _, field := range value {
+		if nanoseconds, ok := field.(float64); ok {
+			return time.Duration(nanoseconds)
+		}
+	}
+
+	return 0
+}
+
+func newLeagueTeamMember(member LeagueTeamMemberResponse) LeagueTeamMember {
+	return LeagueTeamMember{
+		SteamID: steamid.New(member.SteamId),
+		Name:    member.Name,
+		Leader:  member.Leader,
+		Joined:  member.Joined,
+		Left:    member.Left,
+	}
+}
+
+func newLogSummary(summary LogsTFPlayerSummary) LogSummary {
+	return LogSummary{
+		Logs:            int(summary.Logs),
+		Kills:           int(summary.KillsSum),
+		Deaths:          int(summary.DeathsSum),
+		Assists:         int(summary.AssistsSum),
+		Damage:          int(summary.DamageSum),
+		DamageTaken:     int(summary.DamageTakenSum),
+		Airshots:        int(summary.AirshotsSum),
+		Headshots:       int(summary.HeadshotsSum),
+		Backstabs:       int(summary.BackstabsSum),
+		Caps:            int(summary.CapsSum),
+		HealthPacks:     int(summary.HealthPacksSum),
+		HealingTaken:    int(summary.HealingTakenSum),
+		KillsAvg:        float64(summary.KillsAvg.Value),
+		DeathsAvg:       float64(summary.DeathsAvg.Value),
+		AssistsAvg:      float64(summary.AssistsAvg.Value),
+		DamageAvg:       float64(summary.DamageAvg.Value),
+		DamageTakenAvg:  float64(summary.DamageTakenAvg.Value),
+		HealingTakenAvg: float64(summary.HealingTakenAvg.Value),
+		DPMAvg:          float64(summary.DpmAvg.Value),
+		DTMAvg:          float64(summary.DtmAvg.Value),
+		KDAvg:           float64(summary.KdAvg.Value),
+		KADAvg:          float64(summary.KadAvg.Value),
+	}
+}
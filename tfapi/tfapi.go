@@ -2,23 +2,418 @@
 package tfapi
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/leighmacdonald/steamid/v4/steamid"
 )
 
-var ErrNoResult = errors.New("no results")
+// maxBatchSize is the maximum number of steam ids that are sent in a single request.
+const maxBatchSize = 100
+
+var (
+	ErrNoResult = errors.New("no results")
+	ErrResponse = errors.New("invalid api response")
+)
 
 // TFAPI provides a trivial interface around the autogenerated tf-api client just to
 // make any future api changes a bit easier as its still a bit of a moving target.
+//
+// Only the app level types defined in models.go should be exposed to callers so that regenerating
+// the client only requires changes within this package.
 type TFAPI struct {
-	*ClientWithResponses
+	client   *ClientWithResponses
+	profiles *profileCache
+	observer Observer
+}
+
+// Options configures the client, the zero value uses the defaults.
+type Options struct {
+	// Observer is notified about every request made.
+	Observer Observer
+	// ProfileCacheTTL is how long fetched profiles are reused, DefaultProfileCacheTTL is used when zero and
+	// the cache is disabled when negative.
+	ProfileCacheTTL time.Duration
+	// RateLimit is the maximum number of requests per second sent to the api, zero is unlimited.
+	RateLimit float64
+	// UserAgent is sent with every request when set.
+	UserAgent string
 }
 
-func New(host string, client *http.Client) (*TFAPI, error) {
-	tfapiClient, errClient := NewClientWithResponses(host, WithHTTPClient(client))
+// New creates a client that sends its requests using doer, retrying failed requests. Requests are traced
+// using the global otel tracer provider.
+func New(host string, doer HttpRequestDoer, opts Options) (*TFAPI, error) {
+	if opts.Observer == nil {
+		opts.Observer = nopObserver{}
+	}
+
+	if opts.ProfileCacheTTL == 0 {
+		opts.ProfileCacheTTL = DefaultProfileCacheTTL
+	}
+
+	clientOpts := []ClientOption{
+		WithHTTPClient(newTransport(doer, opts.Observer, opts.RateLimit)),
+		WithRequestEditorFn(traceRequest),
+	}
+
+	if opts.UserAgent != "" {
+		clientOpts = append(clientOpts, WithRequestEditorFn(func(_ context.Context, req *http.Request) error {
+			req.Header.Set("User-Agent", opts.UserAgent)
+
+			return nil
+		}))
+	}
+
+	tfapiClient, errClient := NewClientWithResponses(host, clientOpts...)
 	if errClient != nil {
 		return nil, errClient
 	}
 
-	return &TFAPI{ClientWithResponses: tfapiClient}, nil
+	return &TFAPI{client: tfapiClient, profiles: newProfileCache(opts.ProfileCacheTTL), observer: opts.Observer}, nil
+}
+
+// Profile fetches the combined profile of a single player.
+func (t *TFAPI) Profile(ctx context.Context, steamID steamid.SteamID) (Profile, error) {
+	profiles, errProfiles := t.Profiles(ctx, steamID)
+	if errProfiles != nil {
+		return Profile{}, errProfiles
+	}
+
+	if len(profiles) != 1 {
+		return Profile{}, fmt.Errorf("%w: expected 1 profile, got %d", ErrNoResult, len(profiles))
+	}
+
+	return profiles[0], nil
+}
+
+// Profiles fetches the combined profiles of multiple players at once. Large lists of players are split
+// into multiple requests. Recently fetched profiles are served from the cache, Profile.FetchedOn tells
+// callers how old the data is.
+func (t *TFAPI) Profiles(ctx context.Context, steamIDs ...steamid.SteamID) ([]Profile, error) {
+	var (
+		found   = map[steamid.SteamID]Profile{}
+		missing []steamid.SteamID
+	)
+
+	for _, steamID := range steamIDs {
+		profile, cached := t.profiles.get(steamID)
+		if cached {
+			found[steamID] = profile
+		} else {
+			missing = append(missing, steamID)
+		}
+
+		t.observer.ObserveCache(cached)
+	}
+
+	for batch := range slices.Chunk(missing, maxBatchSize) {
+		resp, errResp := t.client.MetaProfileWithResponse(ctx, &MetaProfileParams{Steamids: joinIDs(batch)})
+		if errResp != nil {
+			return nil, errResp
+		}
+
+		results, errResults := result(resp.JSON200, resp.StatusCode(), resp.ApplicationproblemJSONDefault)
+		if errResults != nil {
+			return nil, errResults
+		}
+
+		fetched := make([]Profile, len(results))
+		for i, entry := range results {
+			fetched[i] = newProfile(entry)
+			found[fetched[i].SteamID] = fetched[i]
+		}
+
+		t.profiles.set(fetched...)
+	}
+
+	// Keep the order the players were requested in.
+	profiles := make([]Profile, 0, len(found))
+
+	for _, steamID := range steamIDs {
+		if profile, exists := found[steamID]; exists {
+			profiles = append(profiles, profile)
+			delete(found, steamID)
+		}
+	}
+
+	return profiles, nil
+}
+
+// Bans fetches the 3rd party bans for a player. When siteName is not empty, only bans from
+// the matching site are returned.
+func (t *TFAPI) Bans(ctx context.Context, steamID steamid.SteamID, siteName string) ([]SourceBan, error) {
+	resp, errResp := t.client.BansSearchWithResponse(ctx, &BansSearchParams{
+		Steamids: steamID.String(),
+		SiteName: siteName,
+	})
+	if errResp != nil {
+		return nil, errResp
+	}
+
+	results, errResults := result(resp.JSON200, resp.StatusCode(), resp.ApplicationproblemJSONDefault)
+	if errResults != nil {
+		return nil, errResults
+	}
+
+	bans := make([]SourceBan, len(results))
+	for i, ban := range results {
+		bans[i] = newSourceBan(ban)
+	}
+
+	return bans, nil
+}
+
+// BotDetector searches all the known bot detector lists for entries matching the player.
+func (t *TFAPI) BotDetector(ctx context.Context, steamID steamid.SteamID) ([]BotDetectorMatch, error) {
+	resp, errResp := t.client.BdSearchWithResponse(ctx, &BdSearchParams{Steamids: steamID.String()})
+	if errResp != nil {
+		return nil, errResp
+	}
+
+	results, errResults := result(resp.JSON200, resp.StatusCode(), resp.ApplicationproblemJSONDefault)
+	if errResults != nil {
+		return nil, errResults
+	}
+
+	// The steamid returned within the match is not in a consistent format, since only a single
+	// player is queried we just use that instead.
+	matches := make([]BotDetectorMatch, len(results))
+	for i, match := range results {
+		matches[i] = newBotDetectorMatch(steamID, match)
+	}
+
+	return matches, nil
+}
+
+// SteamBans fetches the current steam ban status of the players. Large lists of players are split into
+// multiple requests.
+func (t *TFAPI) SteamBans(ctx context.Context, steamIDs ...steamid.SteamID) ([]SteamBanState, error) {
+	var states []SteamBanState
+
+	for batch := range slices.Chunk(steamIDs, maxBatchSize) {
+		resp, errResp := t.client.SteamBansWithResponse(ctx, &SteamBansParams{Steamids: joinIDs(batch)})
+		if errResp != nil {
+			return nil, errResp
+		}
+
+		results, errResults := result(resp.JSON200, resp.StatusCode(), resp.ApplicationproblemJSONDefault)
+		if errResults != nil {
+			return nil, errResults
+		}
+
+		for _, ban := range results {
+			states = append(states, newSteamBanState(ban))
+		}
+	}
+
+	return states, nil
+}
+
+// SteamSummaries fetches the current steam profile summaries of the players. Large lists of players are
+// split into multiple requests.
+func (t *TFAPI) SteamSummaries(ctx context.Context, steamIDs ...steamid.SteamID) ([]PlayerSummary, error) {
+	var summaries []PlayerSummary
+
+	for batch := range slices.Chunk(steamIDs, maxBatchSize) {
+		resp, errResp := t.client.SteamSummariesWithResponse(ctx, &SteamSummariesParams{Steamids: joinIDs(batch)})
+		if errResp != nil {
+			return nil, errResp
+		}
+
+		results, errResults := result(resp.JSON200, resp.StatusCode(), resp.ApplicationproblemJSONDefault)
+		if errResults != nil {
+			return nil, errResults
+		}
+
+		for _, summary := range results {
+			summaries = append(summaries, newPlayerSummary(summary))
+		}
+	}
+
+	return summaries, nil
+}
+
+// SteamRep fetches the steamrep.com reputation of the players. Large lists of players are split into
+// multiple requests.
+func (t *TFAPI) SteamRep(ctx context.Context, steamIDs ...steamid.SteamID) ([]Reputation, error) {
+	var reputations []Reputation
+
+	for batch := range slices.Chunk(steamIDs, maxBatchSize) {
+		resp, errResp := t.client.SteamrepQueryWithResponse(ctx, &SteamrepQueryParams{Steamids: joinIDs(batch)})
+		if errResp != nil {
+			return nil, errResp
+		}
+
+		results, errResults := result(resp.JSON200, resp.StatusCode(), resp.ApplicationproblemJSONDefault)
+		if errResults != nil {
+			return nil, errResults
+		}
+
+		for _, entry := range results {
+			reputations = append(reputations, newReputation(entry))
+		}
+	}
+
+	return reputations, nil
+}
+
+// ChatSearch searches the logs.tf chat messages sent by a player. An empty query matches all messages.
+func (t *TFAPI) ChatSearch(ctx context.Context, steamID steamid.SteamID, query string) ([]ChatMessage, error) {
+	resp, errResp := t.client.LogstfChatQueryWithResponse(ctx, &LogstfChatQueryParams{
+		Steamid: steamID.String(),
+		Query:   query,
+	})
+	if errResp != nil {
+		return nil, errResp
+	}
+
+	results, errResults := result(resp.JSON200, resp.StatusCode(), resp.ApplicationproblemJSONDefault)
+	if errResults != nil {
+		return nil, errResults
+	}
+
+	messages := make([]ChatMessage, len(results))
+	for i, message := range results {
+		messages[i] = newChatMessage(message)
+	}
+
+	return messages, nil
+}
+
+// Logs fetches the list of logs.tf matches a player has participated in.
+func (t *TFAPI) Logs(ctx context.Context, steamID steamid.SteamID) ([]Match, error) {
+	resp, errResp := t.client.LogstfMatchListWithResponse(ctx, &LogstfMatchListParams{Steamid: steamID.String()})
+	if errResp != nil {
+		return nil, errResp
+	}
+
+	results, errResults := result(resp.JSON200, resp.StatusCode(), resp.ApplicationproblemJSONDefault)
+	if errResults != nil {
+		return nil, errResults
+	}
+
+	matches := make([]Match, len(results))
+	for i, match := range results {
+		matches[i] = newMatch(match)
+	}
+
+	return matches, nil
+}
+
+// Log fetches the full details of a single logs.tf match.
+func (t *TFAPI) Log(ctx context.Context, logID int64) (MatchDetail, error) {
+	resp, errResp := t.client.LogstfLogWithResponse(ctx, logID)
+	if errResp != nil {
+		return MatchDetail{}, errResp
+	}
+
+	match, errMatch := result(resp.JSON200, resp.StatusCode(), resp.ApplicationproblemJSONDefault)
+	if errMatch != nil {
+		return MatchDetail{}, errMatch
+	}
+
+	return newMatchDetail(match), nil
+}
+
+// TeamMembers fetches the roster of a league team, including players that have since left.
+func (t *TFAPI) TeamMembers(ctx context.Context, league string, leagueID int64) ([]LeagueTeamMember, error) {
+	resp, errResp := t.client.LeaguesTeamMembersWithResponse(ctx, &LeaguesTeamMembersParams{
+		League:   LeaguesTeamMembersParamsLeague(league),
+		LeagueId: leagueID,
+	})
+	if errResp != nil {
+		return nil, errResp
+	}
+
+	results, errResults := result(resp.JSON200, resp.StatusCode(), resp.ApplicationproblemJSONDefault)
+	if errResults != nil {
+		return nil, errResults
+	}
+
+	members := make([]LeagueTeamMember, len(results))
+	for i, member := range results {
+		members[i] = newLeagueTeamMember(member)
+	}
+
+	return members, nil
+}
+
+// LogSummary fetches the aggregated logs.tf stats for a player.
+func (t *TFAPI) LogSummary(ctx context.Context, steamID steamid.SteamID) (LogSummary, error) {
+	resp, errResp := t.client.LogstfPlayerSummaryWithResponse(ctx, &LogstfPlayerSummaryParams{Steamid: steamID.String()})
+	if errResp != nil {
+		return LogSummary{}, errResp
+	}
+
+	summary, errSummary := result(resp.JSON200, resp.StatusCode(), resp.ApplicationproblemJSONDefault)
+	if errSummary != nil {
+		return LogSummary{}, errSummary
+	}
+
+	return newLogSummary(summary), nil
+}
+
+// Sites fetches all the 3rd party ban sources tracked by the api.
+func (t *TFAPI) Sites(ctx context.Context) ([]Site, error) {
+	resp, errResp := t.client.MetaSitesWithResponse(ctx)
+	if errResp != nil {
+		return nil, errResp
+	}
+
+	results, errResults := result(resp.JSON200, resp.StatusCode(), resp.ApplicationproblemJSONDefault)
+	if errResults != nil {
+		return nil, errResults
+	}
+
+	sites := make([]Site, len(results))
+	for i, site := range results {
+		sites[i] = newSite(site)
+	}
+
+	return sites, nil
+}
+
+// Stats fetches the overall counts of the data indexed by the api.
+func (t *TFAPI) Stats(ctx context.Context) (Stats, error) {
+	resp, errResp := t.client.StatsIdWithResponse(ctx)
+	if errResp != nil {
+		return Stats{}, errResp
+	}
+
+	stats, errStats := result(resp.JSON200, resp.StatusCode(), resp.ApplicationproblemJSONDefault)
+	if errStats != nil {
+		return Stats{}, errStats
+	}
+
+	return newStats(stats), nil
+}
+
+// result unwraps the successful response body, returning the best error available when the
+// response was not successful.
+func result[T any](body *T, statusCode int, problem *ErrorModel) (T, error) {
+	var empty T
+
+	if body != nil {
+		return *body, nil
+	}
+
+	if problem != nil && problem.Detail != "" {
+		return empty, fmt.Errorf("%w: %d %s", ErrResponse, statusCode, problem.Detail)
+	}
+
+	return empty, fmt.Errorf("%w: %d %s", ErrResponse, statusCode, http.StatusText(statusCode))
+}
+
+func joinIDs(steamIDs []steamid.SteamID) string {
+	ids := make([]string, len(steamIDs))
+	for i, steamID := range steamIDs {
+		ids[i] = steamID.String()
+	}
+
+	return strings.Join(ids, ",")
 }
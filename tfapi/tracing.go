@@ -0,0 +1,51 @@
+package tfapi
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/leighmacdonald/tf-api-discord/tfapi"
+
+type requestSpanKey struct{}
+
+// traceRequest is a RequestEditorFn that starts a client span for the request and propagates the trace
+// context to tf-api in the request headers. The span is ended by the transport once the request, including
+// any retries, completes.
+func traceRequest(ctx context.Context, req *http.Request) error {
+	spanCtx, span := otel.Tracer(tracerName).Start(ctx, "tfapi "+req.Method+" "+endpointName(req.URL.Path),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("url.path", req.URL.Path),
+			attribute.String("server.address", req.URL.Host),
+		))
+
+	otel.GetTextMapPropagator().Inject(spanCtx, propagation.HeaderCarrier(req.Header))
+
+	*req = *req.WithContext(context.WithValue(spanCtx, requestSpanKey{}, span))
+
+	return nil
+}
+
+func endRequestSpan(span trace.Span, resp *http.Response, err error) {
+	switch {
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	case resp.StatusCode >= http.StatusBadRequest:
+		span.SetStatus(codes.Error, resp.Status)
+	}
+
+	if resp != nil {
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	}
+
+	span.End()
+}
@@ -0,0 +1,244 @@
+package tfapi
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// maxRetries is how many times a failed request is retried before giving up.
+	maxRetries = 2
+	// retryBackoff is the delay before the first retry, doubling for each retry after it.
+	retryBackoff = time.Millisecond * 250
+	// breakerThreshold is the number of consecutive failed requests that opens the breaker.
+	breakerThreshold = 5
+	// breakerCooldown is how long the breaker stays open before a trial request is let through.
+	breakerCooldown = time.Second * 30
+)
+
+var ErrCircuitOpen = errors.New("tf-api is unavailable, try again later")
+
+// BreakerState is the state of the circuit breaker guarding requests to the api.
+type BreakerState int
+
+const (
+	// BreakerClosed lets all requests through.
+	BreakerClosed BreakerState = iota
+	// BreakerHalfOpen lets a single trial request through to check if the api has recovered.
+	BreakerHalfOpen
+	// BreakerOpen fails all requests immediately.
+	BreakerOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerHalfOpen:
+		return "half_open"
+	case BreakerOpen:
+		return "open"
+	default:
+		return "closed"
+	}
+}
+
+// Observer is notified about the requests made to the api, for collecting metrics.
+type Observer interface {
+	// ObserveRequest is called for every request attempt. Status is 0 when no response was received.
+	ObserveRequest(endpoint string, status int, elapsed time.Duration)
+	ObserveRetry(endpoint string)
+	ObserveBreaker(state BreakerState)
+	ObserveCache(hit bool)
+}
+
+type nopObserver struct{}
+
+func (nopObserver) ObserveRequest(string, int, time.Duration) {}
+func (nopObserver) ObserveRetry(string)                       {}
+func (nopObserver) ObserveBreaker(BreakerState)               {}
+func (nopObserver) ObserveCache(bool)                         {}
+
+// transport wraps the callers HttpRequestDoer, retrying failed requests and failing fast while the api is
+// down. All the api endpoints are GET requests without a body, so they are always safe to retry.
+type transport struct {
+	doer     HttpRequestDoer
+	observer Observer
+	breaker  *breaker
+	// limiter is nil when requests are not rate limited.
+	limiter *rate.Limiter
+}
+
+func newTransport(doer HttpRequestDoer, observer Observer, rateLimit float64) *transport {
+	client := &transport{doer: doer, observer: observer, breaker: &breaker{observer: observer}}
+	if rateLimit > 0 {
+		client.limiter = rate.NewLimiter(rate.Limit(rateLimit), max(int(rateLimit), 1))
+	}
+
+	return client
+}
+
+func (t *transport) Do(req *http.Request) (*http.Response, error) {
+	span, traced := req.Context().Value(requestSpanKey{}).(trace.Span)
+	if !traced {
+		return t.do(req, noop.Span{})
+	}
+
+	resp, errDo := t.do(req, span)
+	endRequestSpan(span, resp, errDo)
+
+	return resp, errDo
+}
+
+func (t *transport) do(req *http.Request, span trace.Span) (*http.Response, error) {
+	endpoint := endpointName(req.URL.Path)
+
+	for attempt := 0; ; attempt++ {
+		if t.limiter != nil {
+			if errWait := t.limiter.Wait(req.Context()); errWait != nil {
+				return nil, errWait
+			}
+		}
+
+		if !t.breaker.allow() {
+			return nil, ErrCircuitOpen
+		}
+
+		start := time.Now()
+		resp, errDo := t.doer.Do(req)
+
+		var status int
+		if resp != nil {
+			status = resp.StatusCode
+		}
+
+		t.observer.ObserveRequest(endpoint, status, time.Since(start))
+
+		// Requests cancelled by the caller say nothing about the health of the api.
+		if req.Context().Err() != nil {
+			t.breaker.abort()
+
+			return resp, errDo
+		}
+
+		if !retryable(resp, errDo) {
+			t.breaker.record(true)
+
+			return resp, errDo
+		}
+
+		t.breaker.record(false)
+
+		if attempt == maxRetries {
+			return resp, errDo
+		}
+
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(retryBackoff << attempt):
+		}
+
+		t.observer.ObserveRetry(endpoint)
+		span.AddEvent("retry", trace.WithAttributes(attribute.Int("attempt", attempt+1), attribute.Int("status", status)))
+	}
+}
+
+// retryable reports if the request failed in a way that may succeed when tried again.
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// endpointName replaces the numeric path segments, such as log ids, so each endpoint is reported once.
+func endpointName(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if segment != "" && strings.IndexFunc(segment, func(r rune) bool { return !unicode.IsDigit(r) }) < 0 {
+			segments[i] = ":id"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// breaker opens after breakerThreshold consecutive failures, rejecting requests until the cooldown
+// passes. A single trial request is then let through, closing the breaker again when it succeeds.
+type breaker struct {
+	mu       sync.Mutex
+	observer Observer
+	state    BreakerState
+	failures int
+	openedOn time.Time
+}
+
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedOn) < breakerCooldown {
+			return false
+		}
+
+		b.setState(BreakerHalfOpen)
+
+		return true
+	case BreakerHalfOpen:
+		// Only the trial request is let through until it completes.
+		return false
+	default:
+		return true
+	}
+}
+
+func (b *breaker) record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if success {
+		b.failures = 0
+		b.setState(BreakerClosed)
+
+		return
+	}
+
+	b.failures++
+
+	if b.state == BreakerHalfOpen || b.failures >= breakerThreshold {
+		b.openedOn = time.Now()
+		b.setState(BreakerOpen)
+	}
+}
+
+// abort gives up on a trial request without a result, the next request becomes the trial instead.
+func (b *breaker) abort() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerHalfOpen {
+		b.setState(BreakerOpen)
+	}
+}
+
+func (b *breaker) setState(state BreakerState) {
+	if b.state != state {
+		b.state = state
+		b.observer.ObserveBreaker(state)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/leighmacdonald/steamid/v4/steamid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var errTraceExporter = errors.New("unknown trace exporter")
+
+// tracer is used for all the spans created by the bot, it picks up the provider configured by setupTracing.
+var tracer = otel.Tracer("github.com/leighmacdonald/tf-api-discord")
+
+// setupTracing configures the global tracer provider using the configured exporter:
+//
+//   - "" or "none" disables tracing.
+//   - "otlp" exports to the OTLP/HTTP endpoint from the config, or the standard OTEL_EXPORTER_OTLP_ENDPOINT
+//     env var when unset.
+//   - "stdout" prints the spans, for local debugging.
+//
+// The returned function flushes any pending spans and must be called before exiting.
+func setupTracing(ctx context.Context, conf tracingConfig) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var (
+		exporter    sdktrace.SpanExporter
+		errExporter error
+	)
+
+	switch conf.Exporter {
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	case "otlp":
+		var opts []otlptracehttp.Option
+		if conf.OTLPEndpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(conf.OTLPEndpoint))
+		}
+
+		exporter, errExporter = otlptracehttp.New(ctx, opts...)
+	case "stdout":
+		exporter, errExporter = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("%w: %s", errTraceExporter, conf.Exporter)
+	}
+
+	if errExporter != nil {
+		return nil, errExporter
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", "tf-api-discord"),
+			attribute.String("service.version", version),
+		)),
+	)
+
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// endSpan marks the span as failed when err is set, and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// resolveSteamID is steamid.Resolve within a span, as resolving vanity urls requires a request to steam.
+func resolveSteamID(ctx context.Context, value string) (steamid.SteamID, error) {
+	ctx, span := tracer.Start(ctx, "steamid.resolve", trace.WithAttributes(attribute.String("steamid.query", value)))
+
+	steamID, errResolve := steamid.Resolve(ctx, value)
+	endSpan(span, errResolve)
+
+	return steamID, errResolve
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/leighmacdonald/discordgo-lipstick/bot"
+	"github.com/leighmacdonald/steamid/v4/steamid"
+	"github.com/leighmacdonald/tf-api-discord/store"
+	"github.com/leighmacdonald/tf-api-discord/tfapi"
+)
+
+const watchInterval = time.Minute * 15
+
+var errNotWatched = errors.New("player is not on the watchlist")
+
+func onWatch(api *tfapi.TFAPI, database *store.Store) bot.Handler {
+	return func(ctx context.Context, session *discordgo.Session, interaction *discordgo.InteractionCreate) (*discordgo.MessageEmbed, error) {
+		name, opts := subCommand(interaction)
+
+		switch name {
+		case "add":
+			return onWatchAdd(ctx, api, database, interaction, opts)
+		case "remove":
+			return onWatchRemove(ctx, database, interaction, opts)
+		case "list":
+			return onWatchList(ctx, database, interaction)
+		default:
+			return nil, fmt.Errorf("%w: unknown sub command: %s", bot.ErrCommandInvalid, name)
+		}
+	}
+}
+
+func onWatchAdd(ctx context.Context, api *tfapi.TFAPI, database *store.Store, interaction *discordgo.InteractionCreate, opts bot.CommandOptions) (*discordgo.MessageEmbed, error) {
+	playerID, errPlayerID := resolvePlayer(ctx, database, interaction, opts)
+	if errPlayerID != nil {
+		return nil, errPlayerID
+	}
+
+	// Fetch the profile up front so we fail early on unknown players.
+	profile, errProfile := api.Profile(ctx, playerID)
+	if errProfile != nil {
+		return nil, errors.Join(errProfile, bot.ErrCommandExec)
+	}
+
+	watch := store.Watch{
+		GuildID: interaction.GuildID,
+		SteamID: playerID,
+		Note:    opts.String("note"),
+		AddedBy: interactionUserID(interaction),
+	}
+
+	if err := database.AddWatch(ctx, &watch); err != nil {
+		return nil, errors.Join(err, bot.ErrCommandExec)
+	}
+
+	return newEmbed(ctx, "[Watch] Added "+profile.PersonaName).
+		setProfile(profile).
+		setStatus(profileStatus(profile)).
+		setDescription(watch.Note).
+		build(), nil
+}
+
+func onWatchRemove(ctx context.Context, database *store.Store, interaction *discordgo.InteractionCreate, opts bot.CommandOptions) (*discordgo.MessageEmbed, error) {
+	playerID, errPlayerID := resolvePlayer(ctx, database, interaction, opts)
+	if errPlayerID != nil {
+		return nil, errPlayerID
+	}
+
+	if err := database.RemoveWatch(ctx, interaction.GuildID, playerID); err != nil {
+		if errors.Is(err, store.ErrNoResult) {
+			return nil, errNotWatched
+		}
+
+		return nil, errors.Join(err, bot.ErrCommandExec)
+	}
+
+	return newEmbed(ctx, "[Watch] Removed "+playerID.String()).setSource(sourceBot, time.Time{}).build(), nil
+}
+
+func onWatchList(ctx context.Context, database *store.Store, interaction *discordgo.InteractionCreate) (*discordgo.MessageEmbed, error) {
+	watches, errWatches := database.Watches(ctx, interaction.GuildID)
+	if errWatches != nil {
+		return nil, errors.Join(errWatches, bot.ErrCommandExec)
+	}
+
+	embed := newEmbed(ctx, "[Watch] Watchlist").setSource(sourceBot, time.Time{})
+	if len(watches) == 0 {
+		embed.setDescription("No players are being watched")
+
+		return embed.build(), nil
+	}
+
+	if len(watches) > maxEmbedFields {
+		embed.setDescription(fmt.Sprintf("Showing %d of %d players", maxEmbedFields, len(watches)))
+	}
+
+	for _, watch := range watches {
+		value := fmt.Sprintf("Added by <@%s> on %s", watch.AddedBy, formatDate(ctx, watch.CreatedOn))
+		if watch.Note != "" {
+			value = watch.Note + "\n" + value
+		}
+
+		embed.addFieldInline(watch.SteamID.String(), value)
+	}
+
+	return embed.build(), nil
+}
+
+// watchState is the subset of a players data that is compared between polls to detect changes.
+type watchState struct {
+	PersonaName string   `json:"persona_name"`
+	VACBans     int      `json:"vac_bans"`
+	GameBans    int      `json:"game_bans"`
+	SourceBans  []string `json:"source_bans"`
+	BotDetector []string `json:"bot_detector"`
+	LeagueTeams []string `json:"league_teams"`
+}
+
+func newWatchState(profile tfapi.Profile, matches []tfapi.BotDetectorMatch) watchState {
+	state := watchState{
+		PersonaName: profile.PersonaName,
+		VACBans:     profile.VACBans,
+		GameBans:    profile.GameBans,
+	}
+
+	for _, ban := range profile.Bans {
+		state.SourceBans = append(state.SourceBans,
+			fmt.Sprintf("%s: %s (%s)", ban.SiteName, ban.Reason, ban.CreatedOn.Format(time.DateOnly)))
+	}
+
+	for _, match := range matches {
+		state.BotDetector = append(state.BotDetector, match.ListName)
+	}
+
+	for _, team := range profile.CompetitiveTeams {
+		state.LeagueTeams = append(state.LeagueTeams,
+			fmt.Sprintf("%s %s: %s", team.League, team.SeasonName, team.TeamName))
+	}
+
+	return state
+}
+
+// diff returns a human readable description of everything that changed since the previous state.
+func (s watchState) diff(previous watchState) []string {
+	var changes []string
+
+	if s.VACBans > previous.VACBans {
+		changes = append(changes, fmt.Sprintf("New VAC ban: %d -> %d", previous.VACBans, s.VACBans))
+	}
+
+	if s.GameBans > previous.GameBans {
+		changes = append(changes, fmt.Sprintf("New game ban: %d -> %d", previous.GameBans, s.GameBans))
+	}
+
+	for _, ban := range added(previous.SourceBans, s.SourceBans) {
+		changes = append(changes, "New sourceban: "+ban)
+	}
+
+	for _, list := range added(previous.BotDetector, s.BotDetector) {
+		changes = append(changes, "New bot detector entry: "+list)
+	}
+
+	if s.PersonaName != previous.PersonaName {
+		changes = append(changes, fmt.Sprintf("Name changed: %s -> %s", previous.PersonaName, s.PersonaName))
+	}
+
+	for _, team := range added(previous.LeagueTeams, s.LeagueTeams) {
+		changes = append(changes, "New league team: "+team)
+	}
+
+	return changes
+}
+
+// added returns the values in current that do not exist in previous.
+func added(previous []string, current []string) []string {
+	var results []string
+
+	for _, value := range current {
+		if !slices.Contains(previous, value) {
+			results = append(results, value)
+		}
+	}
+
+	return results
+}
+
+// watcher periodically polls all the watched players and notifies the watching guilds of any changes.
+type watcher struct {
+	api      *tfapi.TFAPI
+	database *store.Store
+	session  *discordgo.Session
+	links    embedConfig
+}
+
+func newWatcher(api *tfapi.TFAPI, database *store.Store, session *discordgo.Session, links embedConfig) *watcher {
+	return &watcher{api: api, database: database, session: session, links: links}
+}
+
+func (w *watcher) start(ctx context.Context) {
+	ctx = withEmbedLinks(ctx, w.links)
+
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.update(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (w *watcher) update(ctx context.Context) {
+	watches, errWatches := w.database.Watches(ctx, "")
+	if errWatches != nil {
+		slog.Error("Failed to load watchlist", slog.String("error", errWatches.Error()))
+
+		return
+	}
+
+	// The same player can be watched by multiple guilds, only fetch them once.
+	guilds := map[steamid.SteamID][]store.Watch{}
+	for _, watch := range watches {
+		guilds[watch.SteamID] = append(guilds[watch.SteamID], watch)
+	}
+
+	for steamID, playerWatches := range guilds {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err := w.updatePlayer(ctx, steamID, playerWatches); err != nil {
+			slog.Error("Failed to update watched player", slog.String("error", err.Error()),
+				slog.String("steam_id", steamID.String()))
+		}
+	}
+}
+
+func (w *watcher) updatePlayer(ctx context.Context, steamID steamid.SteamID, watches []store.Watch) error {
+	profile, errProfile := w.api.Profile(ctx, steamID)
+	if errProfile != nil {
+		return errProfile
+	}
+
+	matches, errMatches := w.api.BotDetector(ctx, steamID)
+	if errMatches != nil {
+		return errMatches
+	}
+
+	current := newWatchState(profile, matches)
+
+	snapshot, errSnapshot := w.database.Snapshot(ctx, steamID, store.SnapshotWatch)
+	if errSnapshot != nil && !errors.Is(errSnapshot, store.ErrNoResult) {
+		return errSnapshot
+	}
+
+	if errSnapshot == nil {
+		var previous watchState
+		if err := snapshot.Decode(&previous); err != nil {
+			return err
+		}
+
+		if changes := current.diff(previous); len(changes) > 0 {
+			w.notify(ctx, profile, watches, changes)
+		}
+	}
+
+	return w.database.SaveSnapshot(ctx, steamID, store.SnapshotWatch, current)
+}
+
+func (w *watcher) notify(ctx context.Context, profile tfapi.Profile, watches []store.Watch, changes []string) {
+	for _, watch := range watches {
+		settings, errSettings := w.database.GuildSettings(ctx, watch.GuildID)
+		if errSettings != nil {
+			slog.Error("Failed to load guild settings", slog.String("error", errSettings.Error()))
+
+			continue
+		}
+
+		if settings.AlertChannelID == "" {
+			slog.Debug("Skipping watch notification, no alert channel set", slog.String("guild_id", watch.GuildID))
+
+			continue
+		}
+
+		if _, err := w.session.ChannelMessageSendEmbed(settings.AlertChannelID, watchEmbed(withGuildSettings(ctx, settings), profile, watch, changes)); err != nil {
+			slog.Error("Failed to send watch notification", slog.String("error", err.Error()),
+				slog.String("guild_id", watch.GuildID))
+		}
+	}
+}
+
+func watchEmbed(ctx context.Context, profile tfapi.Profile, watch store.Watch, changes []string) *discordgo.MessageEmbed {
+	embed := newEmbed(ctx, "[Watch] Changes for "+profile.PersonaName).
+		setProfile(profile).
+		setStatus(max(statusSuspicious, profileStatus(profile))).
+		setDescription(watch.Note)
+
+	for i, change := range changes {
+		embed.addFieldInline("Change #"+strconv.Itoa(i+1), change)
+	}
+
+	return embed.build()
+}